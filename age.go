@@ -0,0 +1,99 @@
+package pwdserv
+
+import (
+	"time"
+)
+
+// PasswordStatus describes whether a password is still valid or has expired.
+type PasswordStatus int
+
+const (
+	// StatusOK the password is valid.
+	StatusOK PasswordStatus = iota
+	// StatusWarn the password expires within the ExpiryWarning period.
+	StatusWarn
+	// StatusExpired the password has expired and must be changed.
+	StatusExpired
+)
+
+// String returns the name of the status.
+func (s PasswordStatus) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusWarn:
+		return "warn"
+	case StatusExpired:
+		return "expired"
+	}
+
+	return "unknown"
+}
+
+// AgeStatus is the result of PasswordService.Status.
+type AgeStatus struct {
+	// Status of the current password.
+	Status PasswordStatus
+	// Expires is the time the current password expires, zero if it never expires.
+	Expires time.Time
+	// Remaining is the time left until the password expires.
+	Remaining time.Duration
+}
+
+// SetClock replaces the clock used by the age checks, mostly useful for testing.
+// Passing nil restores time.Now.
+func (z *PasswordService) SetClock(now func() time.Time) {
+	z.clock = now
+
+	if z.config != nil {
+		z.config.clock = now
+	}
+	if z.candidate != nil {
+		z.candidate.clock = now
+	}
+}
+
+// now returns the current time from the service clock.
+func (z *PasswordService) now() time.Time {
+	if z.clock == nil {
+		return time.Now()
+	}
+	return z.clock()
+}
+
+// Status reports whether the current password of the user is ok, about to expire or expired,
+// depending on the MaxPasswordAge and ExpiryWarning configuration.
+//
+// A password without a PasswordChanged time is reported as expired.
+func (z *PasswordService) Status(model *Password) (*AgeStatus, error) {
+	if z.config == nil {
+		return nil, errNoConfig
+	}
+
+	status := &AgeStatus{Status: StatusOK}
+	if z.config.CheckPasswordAge == false || z.config.MaxPasswordAge <= 0 {
+		return status, nil
+	}
+
+	if model.PasswordChanged.IsZero() {
+		status.Status = StatusExpired
+		return status, nil
+	}
+
+	status.Expires = model.PasswordChanged.Add(days(z.config.MaxPasswordAge))
+	status.Remaining = status.Expires.Sub(z.config.now())
+
+	switch {
+	case status.Remaining <= 0:
+		status.Status = StatusExpired
+		status.Remaining = 0
+	case status.Remaining <= days(z.config.ExpiryWarning):
+		status.Status = StatusWarn
+	}
+
+	return status, nil
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
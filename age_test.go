@@ -0,0 +1,89 @@
+package pwdserv_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Password age", func() {
+	Context("given you have a service configured with age rules", func() {
+		cfgData := []byte(`{
+			"CheckPasswordAge": true,
+			"MinPasswordAge": 1,
+			"MaxPasswordAge": 90,
+			"ExpiryWarning": 14
+		}`)
+		now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		serv := pwdserv.New()
+		var _ = serv.SetConfig(cfgData, nil)
+		serv.SetClock(func() time.Time { return now })
+
+		It("should return error when calling Validate() within the MinPasswordAge.", func() {
+			pwd := pwdserv.Password{
+				NewPassword:     "yVHn6?R@",
+				PasswordChanged: now.Add(-2 * time.Hour),
+			}
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(HaveOccurred())
+
+			errExpect := errors.New("Password may only be changed once every 1 day(s).")
+			Expect(err).To(BeEquivalentTo(errExpect))
+		})
+
+		It("should not return error when calling Validate() after the MinPasswordAge.", func() {
+			pwd := pwdserv.Password{
+				NewPassword:     "yVHn6?R@",
+				PasswordChanged: now.Add(-25 * time.Hour),
+			}
+
+			err := serv.Validate(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should return StatusOK when calling Status() for a recent password.", func() {
+			pwd := pwdserv.Password{PasswordChanged: now.AddDate(0, 0, -30)}
+
+			status, err := serv.Status(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Status).To(Equal(pwdserv.StatusOK))
+			Expect(status.Expires).To(Equal(now.AddDate(0, 0, 60)))
+		})
+
+		It("should return StatusWarn when calling Status() within the ExpiryWarning.", func() {
+			pwd := pwdserv.Password{PasswordChanged: now.AddDate(0, 0, -80)}
+
+			status, err := serv.Status(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Status).To(Equal(pwdserv.StatusWarn))
+			Expect(status.Remaining).To(Equal(10 * 24 * time.Hour))
+		})
+
+		It("should return StatusExpired when calling Status() after the MaxPasswordAge.", func() {
+			pwd := pwdserv.Password{PasswordChanged: now.AddDate(0, 0, -91)}
+
+			status, err := serv.Status(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Status).To(Equal(pwdserv.StatusExpired))
+		})
+
+		It("should return StatusExpired when calling Status() without a PasswordChanged time.", func() {
+			status, err := serv.Status(&pwdserv.Password{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Status).To(Equal(pwdserv.StatusExpired))
+		})
+	})
+
+	It("should return an error if Status() is called before SetConfig().", func() {
+		serv := pwdserv.New()
+
+		_, err := serv.Status(&pwdserv.Password{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,251 @@
+package pwdserv
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes a password change attempt. The Password is redacted,
+// so the record never contains a password, hash or token.
+type AuditRecord struct {
+	// Time of the attempt.
+	Time time.Time
+	// Password is the redacted password that was validated.
+	Password Password
+	// Passed is true if the new password was accepted.
+	Passed bool
+	// Failed holds the names of the validators that failed.
+	Failed []string `json:",omitempty"`
+	// Warned holds the names of the validators that failed without blocking the password change.
+	Warned []string `json:",omitempty"`
+	// Error is set when the validation was cancelled or rate limited.
+	Error string `json:",omitempty"`
+}
+
+// AuditSink receives an AuditRecord for every call to Validate.
+type AuditSink interface {
+	Audit(record *AuditRecord) error
+}
+
+// SetAuditSink sets the sink that every password change attempt is recorded to.
+//
+// If the sink returns an error for an otherwise valid password, Validate returns
+// that error, so unrecorded password changes can not go through.
+func (z *PasswordService) SetAuditSink(sink AuditSink) {
+	z.audit = sink
+}
+
+func (z *PasswordService) emitAudit(model *Password, failures []failure, err error) error {
+	record := AuditRecord{
+		Time:     z.now(),
+		Password: model.Redacted(),
+		Passed:   err == nil && !blocking(failures),
+	}
+
+	for _, f := range failures {
+		if f.blocking() {
+			record.Failed = append(record.Failed, f.name)
+		} else {
+			record.Warned = append(record.Warned, f.name)
+		}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := z.audit.Audit(&record); err != nil {
+		return fmt.Errorf("Audit failed: %w", err)
+	}
+
+	return nil
+}
+
+// auditLine is a line in the audit log. The Hash is the HMAC-SHA256 of the
+// Entry bytes, and every Entry holds the Hash of the line before it.
+type auditLine struct {
+	Entry json.RawMessage
+	Hash  string
+}
+
+type auditEntry struct {
+	Seq      int64
+	PrevHash string
+	Record   *AuditRecord
+}
+
+// AuditHead is the last record of an audit log. The chain can not show that records
+// were removed from the end of the log, so keep the head apart from the log, like in
+// a database or a ticket, and pass it to VerifyAuditLog.
+type AuditHead struct {
+	// Seq is the sequence number of the record, from 1.
+	Seq int64
+	// Hash is the hash of the record.
+	Hash string
+}
+
+// FileAuditSink writes audit records as JSON lines to a file, chaining each line
+// to the previous one with an HMAC keyed from a KeyProvider, so the chain can not
+// be recomputed without the key. Use VerifyAuditLog to detect tampering.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	f    *os.File
+	keys KeyProvider
+	head AuditHead
+}
+
+// NewFileAuditSink opens or creates the audit log at path, chained with the current
+// key of keys. An existing log is verified before new records are appended to its chain.
+func NewFileAuditSink(path string, keys KeyProvider) (*FileAuditSink, error) {
+	if keys == nil {
+		return nil, errors.New("No keys set for the audit log.")
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileAuditSink{f: f, keys: keys}
+	s.head, err = verifyAuditLog(f, keys, nil)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Audit appends the record to the log.
+func (s *FileAuditSink) Audit(record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := currentKey(s.keys)
+	if err != nil {
+		return err
+	}
+
+	entry, err := json.Marshal(auditEntry{Seq: s.head.Seq + 1, PrevHash: s.head.Hash, Record: record})
+	if err != nil {
+		return err
+	}
+
+	hash := hex.EncodeToString(sum(key, entry))
+
+	line, err := json.Marshal(auditLine{Entry: entry, Hash: hash})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	s.head = AuditHead{Seq: s.head.Seq + 1, Hash: hash}
+
+	return nil
+}
+
+// Head returns the last record written, to keep apart from the log.
+func (s *FileAuditSink) Head() AuditHead {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.head
+}
+
+// Close closes the log file.
+func (s *FileAuditSink) Close() error {
+	return s.f.Close()
+}
+
+// VerifyAuditLog checks the chain of an audit log written by FileAuditSink with the
+// keys, current or previous, and returns its head. The error names the first line
+// that was modified, removed or inserted.
+//
+// When head is not nil, the log must hold the record of the head, so records
+// removed from the end of the log, or a log rewritten from the start, are detected too.
+func VerifyAuditLog(r io.Reader, keys KeyProvider, head *AuditHead) (AuditHead, error) {
+	last, err := verifyAuditLog(r, keys, head)
+	if err != nil || head == nil {
+		return last, err
+	}
+
+	if last.Seq < head.Seq {
+		return last, fmt.Errorf("Audit log is truncated at record %d, the head is record %d.", last.Seq, head.Seq)
+	}
+
+	return last, nil
+}
+
+func verifyAuditLog(r io.Reader, keys KeyProvider, anchor *AuditHead) (AuditHead, error) {
+	var head AuditHead
+
+	all, err := keys.Keys()
+	if err != nil {
+		return head, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var line auditLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return head, fmt.Errorf("Audit log line %d: %w", n, err)
+		}
+
+		if !signedBy(all, line.Entry, line.Hash) {
+			return head, fmt.Errorf("Audit log line %d: hash mismatch.", n)
+		}
+
+		var entry auditEntry
+		if err := json.Unmarshal(line.Entry, &entry); err != nil {
+			return head, fmt.Errorf("Audit log line %d: %w", n, err)
+		}
+
+		if entry.PrevHash != head.Hash || entry.Seq != head.Seq+1 {
+			return head, fmt.Errorf("Audit log line %d: broken chain.", n)
+		}
+
+		if anchor != nil && entry.Seq == anchor.Seq && line.Hash != anchor.Hash {
+			return head, fmt.Errorf("Audit log line %d: does not match the head.", n)
+		}
+
+		head = AuditHead{Seq: entry.Seq, Hash: line.Hash}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return head, err
+	}
+
+	return head, nil
+}
+
+// signedBy reports whether hash is the HMAC of data with any of the keys.
+func signedBy(keys [][]byte, data []byte, hash string) bool {
+	want, err := hex.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+
+	for _, key := range keys {
+		if hmac.Equal(sum(key, data), want) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package pwdserv_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	var (
+		dir  string
+		keys = pwdserv.StaticKeys([]byte("0123456789abcdef-audit"))
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "pwdserv")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("given you have a service with a file audit sink", func() {
+		It("should record the attempts without passwords in a verifiable chain.", func() {
+			path := filepath.Join(dir, "audit.log")
+			sink, err := pwdserv.NewFileAuditSink(path, keys)
+			Expect(err).ToNot(HaveOccurred())
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{"CheckMinLength": true, "MinLength": 8}`), nil)
+			serv.SetAuditSink(sink)
+
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", ApplicationID: "APP1", NewPassword: "yVHn6?R@", OldPassword: "B1ge@rs*"})
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", ApplicationID: "APP1", NewPassword: "yVH6@"})
+			Expect(sink.Close()).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("ABHW089"))
+			Expect(string(data)).To(ContainSubstring(`"Failed":["CL"]`))
+			Expect(string(data)).ToNot(ContainSubstring("yVHn6?R@"))
+			Expect(string(data)).ToNot(ContainSubstring("B1ge@rs*"))
+			Expect(string(data)).ToNot(ContainSubstring("yVH6@"))
+
+			head, err := pwdserv.VerifyAuditLog(bytes.NewReader(data), keys, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(head.Seq).To(Equal(int64(2)))
+
+			sink, err = pwdserv.NewFileAuditSink(path, keys)
+			Expect(err).ToNot(HaveOccurred())
+			serv.SetAuditSink(sink)
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@"})
+			Expect(sink.Close()).To(Succeed())
+
+			data, err = os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			head, err = pwdserv.VerifyAuditLog(bytes.NewReader(data), keys, &head)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(head.Seq).To(Equal(int64(3)))
+			Expect(head).To(Equal(sink.Head()))
+		})
+
+		It("should detect a modified record when calling VerifyAuditLog().", func() {
+			var buf bytes.Buffer
+			path := filepath.Join(dir, "audit.log")
+			sink, err := pwdserv.NewFileAuditSink(path, keys)
+			Expect(err).ToNot(HaveOccurred())
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{"CheckMinLength": true, "MinLength": 8}`), nil)
+			serv.SetAuditSink(sink)
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVH6@"})
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@"})
+			Expect(sink.Close()).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			buf.Write(bytes.Replace(data, []byte(`"Passed":false`), []byte(`"Passed":true`), 1))
+
+			_, err = pwdserv.VerifyAuditLog(&buf, keys, nil)
+			Expect(err).To(MatchError("Audit log line 1: hash mismatch."))
+
+			_, err = pwdserv.VerifyAuditLog(bytes.NewReader(data), pwdserv.StaticKeys([]byte("0123456789abcdef-other")), nil)
+			Expect(err).To(MatchError("Audit log line 1: hash mismatch."))
+
+			_, err = pwdserv.NewFileAuditSink(path+".missing/audit.log", keys)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should detect records removed from the end with the head.", func() {
+			path := filepath.Join(dir, "audit.log")
+			sink, err := pwdserv.NewFileAuditSink(path, keys)
+			Expect(err).ToNot(HaveOccurred())
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{"CheckMinLength": true, "MinLength": 8}`), nil)
+			serv.SetAuditSink(sink)
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVH6@"})
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@"})
+			head := sink.Head()
+			Expect(sink.Close()).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			lines := bytes.SplitAfter(data, []byte("\n"))
+
+			_, err = pwdserv.VerifyAuditLog(bytes.NewReader(lines[0]), keys, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pwdserv.VerifyAuditLog(bytes.NewReader(lines[0]), keys, &head)
+			Expect(err).To(MatchError("Audit log is truncated at record 1, the head is record 2."))
+
+			head.Hash = "00"
+			_, err = pwdserv.VerifyAuditLog(bytes.NewReader(data), keys, &head)
+			Expect(err).To(MatchError("Audit log line 2: does not match the head."))
+		})
+	})
+
+	It("should redact every password field when calling Redacted().", func() {
+		pwd := pwdserv.Password{
+			UserID:          "ABHW089",
+			JWTToken:        "token",
+			OldPassword:     "B1ge@rs*",
+			NewPassword:     "yVHn6?R@",
+			ConfirmPassword: "yVHn6?R@",
+			PasswordHistory: []string{"$sG96r#X"},
+			NewPasswordHash: "yVHn6?R@",
+			History:         []pwdserv.HistoryEntry{{Hash: "3g9m&9W7"}},
+			UserContext:     pwdserv.UserContext{FirstName: "Jane", Email: "jane@digirazor.com", Words: []string{"Cape Town"}},
+		}
+
+		r := pwd.Redacted()
+		Expect(r.UserID).To(Equal("ABHW089"))
+		Expect(r.JWTToken).To(Equal("[REDACTED]"))
+		Expect(r.OldPassword).To(Equal("[REDACTED]"))
+		Expect(r.NewPassword).To(Equal("[REDACTED]"))
+		Expect(r.ConfirmPassword).To(Equal("[REDACTED]"))
+		Expect(r.NewPasswordHash).To(Equal("[REDACTED]"))
+		Expect(r.PasswordHistory).To(Equal([]string{"[REDACTED]"}))
+		Expect(r.History[0].Hash).To(Equal("[REDACTED]"))
+		Expect(r.UserContext).To(Equal(pwdserv.UserContext{FirstName: "[REDACTED]", Email: "[REDACTED]", Words: []string{"[REDACTED]"}}))
+		Expect(pwd.NewPassword).To(Equal("yVHn6?R@"))
+	})
+})
@@ -0,0 +1,238 @@
+package pwdserv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+//go:embed lists/*.txt
+var lists embed.FS
+
+// BlackListSource provides the words of a black list to LoadBlackList.
+type BlackListSource interface {
+	// ReadWords calls add for every word of the list.
+	ReadWords(add func(word string)) error
+}
+
+// BlackListOptions are the preprocessing of the words loaded by LoadBlackList.
+type BlackListOptions struct {
+	// MinLength drops the words shorter than MinLength characters,
+	// so a word like "a" does not reject nearly every password.
+	MinLength int
+	// KeepCase keeps the case of the words. By default they are lower-cased,
+	// as CheckBlackList ignores case anyway, so duplicates only differing in
+	// case are dropped.
+	KeepCase bool
+}
+
+// LoadBlackList reads the words of the sources, in order, for SetConfig.
+// Duplicate words are dropped.
+func LoadBlackList(opts BlackListOptions, sources ...BlackListSource) ([]string, error) {
+	var words []string
+	seen := make(map[string]bool)
+
+	add := func(word string) {
+		if !opts.KeepCase {
+			word = strings.ToLower(word)
+		}
+		if utf8.RuneCountInString(word) < opts.MinLength || seen[word] {
+			return
+		}
+
+		seen[word] = true
+		words = append(words, word)
+	}
+
+	for _, src := range sources {
+		if err := src.ReadWords(add); err != nil {
+			return nil, err
+		}
+	}
+
+	return words, nil
+}
+
+type wordsSource []string
+
+func (s wordsSource) ReadWords(add func(word string)) error {
+	for _, word := range s {
+		if word = strings.TrimSpace(word); word != "" {
+			add(word)
+		}
+	}
+
+	return nil
+}
+
+// BlackListWords is a source of the words, like the slice passed to SetConfig.
+func BlackListWords(words ...string) BlackListSource {
+	return wordsSource(words)
+}
+
+type readerSource struct {
+	name string
+	r    io.Reader
+}
+
+func (s readerSource) ReadWords(add func(word string)) error {
+	if err := readWords(s.r, add); err != nil {
+		return fmt.Errorf("Black list '%s': %w", s.name, err)
+	}
+
+	return nil
+}
+
+// BlackListReader is a source of a list read from r, with one word per line.
+// Empty lines and lines starting with # are skipped. Lists compressed with
+// gzip or zstd are decompressed.
+func BlackListReader(name string, r io.Reader) BlackListSource {
+	return readerSource{name, r}
+}
+
+type fileSource string
+
+func (s fileSource) ReadWords(add func(word string)) error {
+	f, err := os.Open(string(s))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return readerSource{string(s), f}.ReadWords(add)
+}
+
+// BlackListFile is a source of the list in the file, in the format of BlackListReader.
+func BlackListFile(path string) BlackListSource {
+	return fileSource(path)
+}
+
+type fsSource struct {
+	fsys    fs.FS
+	pattern string
+}
+
+func (s fsSource) ReadWords(add func(word string)) error {
+	names, err := fs.Glob(s.fsys, s.pattern)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("Black list '%s' not found.", s.pattern)
+	}
+
+	for _, name := range names {
+		if err := s.readFile(name, add); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s fsSource) readFile(name string, add func(word string)) error {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return readerSource{name, f}.ReadWords(add)
+}
+
+// BlackListFS is a source of the lists in fsys matching the pattern, like an embed.FS
+// with the lists of the application, in the format of BlackListReader.
+func BlackListFS(fsys fs.FS, pattern string) BlackListSource {
+	return fsSource{fsys, pattern}
+}
+
+type dirSource string
+
+func (s dirSource) ReadWords(add func(word string)) error {
+	var names []string
+
+	err := filepath.WalkDir(string(s), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && !strings.HasPrefix(d.Name(), ".") {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fileSource(name).ReadWords(add); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlackListDir is a source of every list in the directory and its sub-directories,
+// in the order of their names. Hidden files are skipped.
+func BlackListDir(dir string) BlackListSource {
+	return dirSource(dir)
+}
+
+// DefaultBlackList is a source of the default list of common words embedded in pwdserv.
+func DefaultBlackList() BlackListSource {
+	return fsSource{lists, path.Join("lists", "default.txt")}
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// readWords reads a list with one word per line, decompressing it if needed.
+func readWords(r io.Reader, add func(word string)) error {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	var lines io.Reader = br
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		lines = gz
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		lines = zr
+	}
+
+	scanner := bufio.NewScanner(lines)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		add(word)
+	}
+
+	return scanner.Err()
+}
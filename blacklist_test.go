@@ -0,0 +1,118 @@
+package pwdserv_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	"github.com/DigiRazor/pwdserv"
+	"github.com/klauspost/compress/zstd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Black list sources", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "pwdserv")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, data, 0o644)).To(Succeed())
+		return path
+	}
+
+	Context("given you have lists in files", func() {
+		It("should skip comments and empty lines of a plain list.", func() {
+			path := write("words.txt", []byte("# common words\nTest\n\n  password  \n"))
+
+			words, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.BlackListFile(path))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(words).To(Equal([]string{"test", "password"}))
+		})
+
+		It("should decompress gzip and zstd lists.", func() {
+			var gz bytes.Buffer
+			w := gzip.NewWriter(&gz)
+			w.Write([]byte("summer\nwinter\n"))
+			w.Close()
+
+			var zs bytes.Buffer
+			zw, _ := zstd.NewWriter(&zs)
+			zw.Write([]byte("spring\nautumn\n"))
+			zw.Close()
+
+			words, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{},
+				pwdserv.BlackListFile(write("seasons.txt.gz", gz.Bytes())),
+				pwdserv.BlackListReader("seasons.zst", &zs),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(words).To(Equal([]string{"summer", "winter", "spring", "autumn"}))
+		})
+
+		It("should read every list of a directory in name order.", func() {
+			write("b.txt", []byte("beta\n"))
+			write("a.txt", []byte("alpha\n"))
+			write("sub/c.txt", []byte("gamma\n"))
+			write(".hidden", []byte("delta\n"))
+
+			words, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.BlackListDir(dir))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(words).To(Equal([]string{"alpha", "beta", "gamma"}))
+		})
+
+		It("should return an error for a missing file.", func() {
+			_, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.BlackListFile(filepath.Join(dir, "missing.txt")))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("given you have embedded lists", func() {
+		It("should read the lists matching the pattern.", func() {
+			fsys := fstest.MapFS{
+				"lists/a.txt": {Data: []byte("acme\n")},
+				"lists/b.txt": {Data: []byte("payroll\n")},
+			}
+
+			words, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.BlackListFS(fsys, "lists/*.txt"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(words).To(Equal([]string{"acme", "payroll"}))
+		})
+
+		It("should read the default list.", func() {
+			words, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.DefaultBlackList())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(words).To(ContainElement("password"))
+			Expect(words).ToNot(ContainElement(HavePrefix("#")))
+		})
+	})
+
+	Context("given you preprocess the words", func() {
+		It("should dedup, lower-case and drop short words.", func() {
+			words, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{MinLength: 3},
+				pwdserv.BlackListWords("Test", "a", "TEST", "ok", "acme"),
+				pwdserv.BlackListReader("more", strings.NewReader("test\nAcme\n")),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(words).To(Equal([]string{"test", "acme"}))
+		})
+
+		It("should keep the case when asked.", func() {
+			words, _ := pwdserv.LoadBlackList(pwdserv.BlackListOptions{KeepCase: true}, pwdserv.BlackListWords("Test", "test"))
+			Expect(words).To(Equal([]string{"Test", "test"}))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+// Copyright 2017 DigiRazor (Pty) Ltd. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be found
+// in the LICENSE file.
+
+// Package celrules evaluates pwdserv ExprRules with the Common Expression Language (CEL).
+//
+//	engine, err := celrules.New()
+//	if err != nil {
+//		return err
+//	}
+//	serv.SetExprEngine(engine)
+//
+// The expressions are type-checked when the configuration is loaded, and have
+// the password features as variables: length, upper, lower, numeric, special,
+// symbol, space, other, classes and historySize (int), entropy (double),
+// userID and applicationID (string).
+package celrules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DigiRazor/pwdserv"
+	"github.com/google/cel-go/cel"
+)
+
+// Engine implements pwdserv.ExprEngine with CEL.
+type Engine struct {
+	env *cel.Env
+}
+
+// New creates the CEL environment with the password features declared.
+func New() (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("length", cel.IntType),
+		cel.Variable("upper", cel.IntType),
+		cel.Variable("lower", cel.IntType),
+		cel.Variable("numeric", cel.IntType),
+		cel.Variable("special", cel.IntType),
+		cel.Variable("symbol", cel.IntType),
+		cel.Variable("space", cel.IntType),
+		cel.Variable("other", cel.IntType),
+		cel.Variable("classes", cel.IntType),
+		cel.Variable("historySize", cel.IntType),
+		cel.Variable("entropy", cel.DoubleType),
+		cel.Variable("userID", cel.StringType),
+		cel.Variable("applicationID", cel.StringType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Engine{env: env}, nil
+}
+
+// Compile type-checks the expression, which must evaluate to a bool.
+func (e *Engine) Compile(expr string) (pwdserv.ExprProgram, error) {
+	ast, iss := e.env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("Expression must be a bool, not %s.", ast.OutputType())
+	}
+
+	prg, err := e.env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	return &program{prg: prg}, nil
+}
+
+type program struct {
+	prg cel.Program
+}
+
+// Eval evaluates the program with the features as variables.
+func (p *program) Eval(f pwdserv.Features) (bool, error) {
+	out, _, err := p.prg.Eval(map[string]interface{}{
+		"length":        f.Length,
+		"upper":         f.Upper,
+		"lower":         f.Lower,
+		"numeric":       f.Numeric,
+		"special":       f.Special,
+		"symbol":        f.Symbol,
+		"space":         f.Space,
+		"other":         f.Other,
+		"classes":       f.Classes,
+		"historySize":   f.HistorySize,
+		"entropy":       f.Entropy,
+		"userID":        f.UserID,
+		"applicationID": f.ApplicationID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	ok, isBool := out.Value().(bool)
+	if !isBool {
+		return false, errors.New("Expression did not evaluate to a bool.")
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,13 @@
+package celrules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCelrules(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Celrules Suite")
+}
@@ -0,0 +1,75 @@
+package celrules_test
+
+import (
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+	"github.com/DigiRazor/pwdserv/celrules"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Celrules", func() {
+	var serv *pwdserv.PasswordService
+
+	BeforeEach(func() {
+		engine, err := celrules.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		serv = pwdserv.New()
+		serv.SetExprEngine(engine)
+	})
+
+	Context("given you have a rule for 3 of 4 classes unless the password is long", func() {
+		BeforeEach(func() {
+			err := serv.SetConfig([]byte(`{"ExprRules": [
+				{"Code": "Complexity", "Expr": "classes >= 3 || length >= 16",
+				 "Message": "Password must use 3 kinds of characters, or be at least 16 characters."}
+			]}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should pass a password with 3 classes when calling Validate().", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yvhn6?r@"})).To(Succeed())
+		})
+
+		It("should pass a long password with 1 class when calling Validate().", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "correcthorsebatterystaple"})).To(Succeed())
+		})
+
+		It("should return the rule Message for a short password with 2 classes when calling Validate().", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "yvhn6rxz"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password must use 3 kinds of characters, or be at least 16 characters.")))
+		})
+	})
+
+	Context("given you have a rule on the user and history", func() {
+		It("should evaluate the string and history features when calling Validate().", func() {
+			err := serv.SetConfig([]byte(`{"ExprRules": [
+				{"Code": "Admin", "Expr": "userID != 'admin' || (entropy > 40.0 && historySize > 0)",
+				 "Message": "Administrators need a strong password and history."}
+			]}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@"})).To(Succeed())
+
+			err = serv.Validate(&pwdserv.Password{UserID: "admin", NewPassword: "yVHn6?R@"})
+			Expect(err).To(BeEquivalentTo(errors.New("Administrators need a strong password and history.")))
+
+			Expect(serv.Validate(&pwdserv.Password{UserID: "admin", NewPassword: "yVHn6?R@xQ2!", PasswordHistory: []string{"x"}})).To(Succeed())
+		})
+	})
+
+	Context("given you have an invalid rule", func() {
+		It("should return the type error when calling SetConfig().", func() {
+			err := serv.SetConfig([]byte(`{"ExprRules": [{"Code": "Bad", "Expr": "length + 1", "Message": "Bad."}]}`), nil)
+			Expect(err).To(MatchError(ContainSubstring("Expression rule 'Bad': Expression must be a bool")))
+		})
+
+		It("should return the compile error of an unknown feature when calling SetConfig().", func() {
+			err := serv.SetConfig([]byte(`{"ExprRules": [{"Code": "Bad", "Expr": "digits > 1", "Message": "Bad."}]}`), nil)
+			Expect(err).To(MatchError(ContainSubstring("undeclared reference to 'digits'")))
+		})
+	})
+})
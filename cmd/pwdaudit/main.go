@@ -0,0 +1,94 @@
+// Copyright 2017 DigiRazor (Pty) Ltd. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be found
+// in the LICENSE file.
+
+// Command pwdaudit verifies the chain of audit logs written by pwdserv.FileAuditSink.
+//
+// Usage:
+//
+//	pwdaudit verify -keys KEYFILE [-head SEQ:HASH] FILE...
+//
+// The keys are read with pwdserv.FileKeys. With -head the log must hold the
+// head recorded by FileAuditSink.Head, so a truncated log is detected too.
+// The exit status is 1 if any of the logs has been tampered with.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DigiRazor/pwdserv"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "verify" {
+		usage()
+	}
+
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	keyFile := flags.String("keys", "", "file with the base64 keys of the chain, current first")
+	headFlag := flags.String("head", "", "head of the log as SEQ:HASH")
+	flags.Parse(os.Args[2:])
+
+	if *keyFile == "" || flags.NArg() == 0 || (*headFlag != "" && flags.NArg() > 1) {
+		usage()
+	}
+
+	keys, err := pwdserv.FileKeys(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", *keyFile, err)
+		os.Exit(2)
+	}
+
+	var head *pwdserv.AuditHead
+	if *headFlag != "" {
+		if head, err = parseHead(*headFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		if err := verify(path, keys, head); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			status = 1
+		}
+	}
+
+	os.Exit(status)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pwdaudit verify -keys KEYFILE [-head SEQ:HASH] FILE...")
+	os.Exit(2)
+}
+
+func parseHead(s string) (*pwdserv.AuditHead, error) {
+	seq, hash, ok := strings.Cut(s, ":")
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if !ok || err != nil || hash == "" {
+		return nil, fmt.Errorf("Invalid head '%s', expected SEQ:HASH.", s)
+	}
+
+	return &pwdserv.AuditHead{Seq: n, Hash: hash}, nil
+}
+
+func verify(path string, keys pwdserv.KeyProvider, head *pwdserv.AuditHead) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	last, err := pwdserv.VerifyAuditLog(f, keys, head)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: ok, %d records, head %d:%s\n", path, last.Seq, last.Seq, last.Hash)
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 DigiRazor (Pty) Ltd. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be found
+// in the LICENSE file.
+
+// Command pwdshadow summarises the disagreements between the active and candidate
+// configuration written by pwdserv.ShadowWriter.
+//
+// Usage:
+//
+//	pwdshadow report FILE...
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/DigiRazor/pwdserv"
+)
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "report" {
+		fmt.Fprintln(os.Stderr, "usage: pwdshadow report FILE...")
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, path := range os.Args[2:] {
+		if err := report(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			status = 1
+		}
+	}
+
+	os.Exit(status)
+}
+
+func report(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sum, err := pwdserv.SummarizeShadow(f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d disagreements\n", path, sum.Records)
+	fmt.Printf("  would be rejected: %d\n", sum.Rejected)
+	fmt.Printf("  would be accepted: %d\n", sum.Accepted)
+
+	names := make([]string, 0, len(sum.Rules))
+	for name := range sum.Rules {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if sum.Rules[names[i]] != sum.Rules[names[j]] {
+			return sum.Rules[names[i]] > sum.Rules[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		fmt.Printf("  %-20s %d\n", name, sum.Rules[name])
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 DigiRazor (Pty) Ltd. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be found
+// in the LICENSE file.
+
+//go:build js && wasm
+
+// Command pwdwasm runs the pwdserv validators in the browser, so the feedback while
+// typing matches the server. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o pwdserv.wasm github.com/DigiRazor/pwdserv/cmd/pwdwasm
+//
+// and load it with wasm_exec.js from the Go distribution. It registers a global
+// pwdserv object:
+//
+//	pwdserv.validate(configJSON, passwordJSON) // returns the error text or ""
+//	pwdserv.export(configJSON, locale)         // returns the ClientPolicy as JSON
+//
+// The configJSON is the same as passed to SetConfig on the server. The rules that
+// Export marks as ServerOnly, like the history and the black list, are not checked,
+// as the browser has no data for them.
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/DigiRazor/pwdserv"
+)
+
+func main() {
+	js.Global().Set("pwdserv", js.ValueOf(map[string]interface{}{
+		"validate": js.FuncOf(validate),
+		"export":   js.FuncOf(export),
+	}))
+
+	select {}
+}
+
+func validate(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return "usage: pwdserv.validate(configJSON, passwordJSON)"
+	}
+
+	serv, err := load(args[0].String())
+	if err != nil {
+		return err.Error()
+	}
+
+	policy, err := serv.Export(pwdserv.DefaultLocale)
+	if err != nil {
+		return err.Error()
+	}
+	for _, rule := range policy.Rules {
+		if rule.ServerOnly {
+			serv.Disable(rule.Code)
+		}
+	}
+
+	var pwd pwdserv.Password
+	if err := json.Unmarshal([]byte(args[1].String()), &pwd); err != nil {
+		return err.Error()
+	}
+
+	if err := serv.Validate(&pwd); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+func export(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return "usage: pwdserv.export(configJSON, locale)"
+	}
+
+	serv, err := load(args[0].String())
+	if err != nil {
+		return err.Error()
+	}
+
+	policy, err := serv.Export(args[1].String())
+	if err != nil {
+		return err.Error()
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(data)
+}
+
+// load configures a service for the browser. Server-only rules are loaded with
+// stand-ins for what only the server has, so the configuration of the server loads.
+func load(config string) (*pwdserv.PasswordService, error) {
+	serv := pwdserv.New()
+	serv.SetExprEngine(serverOnly{})
+	serv.SetHistoryDecrypter(pwdserv.PlainHistory{})
+	serv.SetHistoryHasher(serverOnly{})
+
+	if err := serv.SetConfig([]byte(config), nil); err != nil {
+		return nil, err
+	}
+
+	return serv, nil
+}
+
+// serverOnly is the ExprEngine and HistoryHasher in the browser: expression rules
+// and the hashed history are server-only.
+type serverOnly struct{}
+
+func (serverOnly) Compile(expr string) (pwdserv.ExprProgram, error) {
+	return serverOnly{}, nil
+}
+
+func (serverOnly) Eval(f pwdserv.Features) (bool, error) {
+	return true, nil
+}
+
+// Matches never matches, as the hashed history is server-only.
+func (serverOnly) Matches(password, hash string) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,52 @@
+package pwdserv_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type traceKey struct{}
+
+var _ = Describe("Context-aware validators", func() {
+	Context("given you have a service with a context-aware validator", func() {
+		var traced string
+
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{}`), nil)
+		serv.AddContext("Trace", func(ctx context.Context, password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+			traced, _ = ctx.Value(traceKey{}).(string)
+			return true, nil
+		})
+
+		It("should pass request-scoped values when calling ValidateContext().", func() {
+			ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+
+			err := serv.ValidateContext(ctx, &pwdserv.Password{NewPassword: "yVHn6?R@"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(traced).To(Equal("trace-1"))
+		})
+
+		It("should return the context error when calling ValidateContext() with a cancelled context.", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := serv.ValidateContext(ctx, &pwdserv.Password{NewPassword: "yVHn6?R@"})
+			Expect(err).To(Equal(context.Canceled))
+		})
+	})
+
+	It("should adapt a Validation when calling AdaptValidation().", func() {
+		val := pwdserv.AdaptValidation(func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+			return false, errors.New("Adapted.")
+		})
+
+		isvalid, err := val(context.Background(), &pwdserv.Password{}, &pwdserv.PasswordRules{})
+		Expect(isvalid).To(BeFalse())
+		Expect(err).To(MatchError("Adapted."))
+	})
+})
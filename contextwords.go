@@ -0,0 +1,135 @@
+package pwdserv
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UserContext holds details of the user that should not be part of their password,
+// checked with the CheckContextWords config switch.
+type UserContext struct {
+	// FirstName of the user.
+	FirstName string `json:",omitempty"`
+	// LastName of the user.
+	LastName string `json:",omitempty"`
+	// Email of the user; the name and the domain, without its top-level, are checked.
+	Email string `json:",omitempty"`
+	// Organisation the user belongs to.
+	Organisation string `json:",omitempty"`
+	// Words are any other terms related to the user, like a department or city.
+	Words []string `json:",omitempty"`
+}
+
+// ErrContextWord can be used with errors.Is to detect a *ContextWordError.
+var ErrContextWord = &ContextWordError{}
+
+// ContextWordError is returned by CheckContextWords for a password that contains
+// a word of the application or user context.
+type ContextWordError struct {
+	// Word that the password contains.
+	Word string
+}
+
+func (e *ContextWordError) Error() string {
+	return fmt.Sprintf("Password contains the context word '%s'.", e.Word)
+}
+
+// Is reports whether target is a *ContextWordError.
+func (e *ContextWordError) Is(target error) bool {
+	_, ok := target.(*ContextWordError)
+	return ok
+}
+
+// defaultContextWordLength is the minimum length of context words when
+// MinContextWordLength is not set.
+const defaultContextWordLength = 4
+
+// CheckContextWords validator checks the NewPassword against the ContextWords of the
+// ApplicationID, and the terms derived from the ApplicationID and UserContext.
+// Words match like the BlackList: ignoring case, anywhere in the password.
+func CheckContextWords(password *Password, config *PasswordRules) (bool, error) {
+	if config.CheckContextWords == true {
+		for _, word := range contextWords(password, config) {
+			if containsFold(password.NewPassword, word) {
+				return false, &ContextWordError{Word: word}
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// contextWords returns the context words of the password, longest first,
+// so the error names the most specific word.
+func contextWords(password *Password, config *PasswordRules) []string {
+	minLength := config.MinContextWordLength
+	if minLength == 0 {
+		minLength = defaultContextWordLength
+	}
+
+	var words []string
+	add := func(word string) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if utf8.RuneCountInString(word) < minLength {
+			return
+		}
+		for _, w := range words {
+			if w == word {
+				return
+			}
+		}
+		words = append(words, word)
+	}
+
+	for _, word := range config.ContextWords["*"] {
+		add(word)
+	}
+	if password.ApplicationID != "" {
+		for _, word := range config.ContextWords[password.ApplicationID] {
+			add(word)
+		}
+	}
+
+	addTerms := func(s string) {
+		terms := strings.FieldsFunc(s, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		add(strings.Join(terms, ""))
+		for _, term := range terms {
+			add(term)
+		}
+	}
+
+	addTerms(password.ApplicationID)
+
+	user := password.UserContext
+	addTerms(user.FirstName)
+	addTerms(user.LastName)
+	addTerms(user.Organisation)
+	for _, word := range user.Words {
+		addTerms(word)
+	}
+
+	if name, domain, ok := strings.Cut(user.Email, "@"); ok {
+		addTerms(name)
+		if i := strings.LastIndexByte(domain, '.'); i >= 0 {
+			domain = domain[:i]
+		}
+		addTerms(domain)
+	}
+
+	// longest first, so "payrollportal" is reported before "payroll"
+	for i := 1; i < len(words); i++ {
+		for j := i; j > 0 && len(words[j]) > len(words[j-1]); j-- {
+			words[j], words[j-1] = words[j-1], words[j]
+		}
+	}
+
+	return words
+}
+
+func describeContextWords(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckContextWords, ValidatorContextWords, nil)
+}
@@ -0,0 +1,81 @@
+package pwdserv_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Context words", func() {
+	var serv *pwdserv.PasswordService
+
+	BeforeEach(func() {
+		serv = pwdserv.New()
+		err := serv.SetConfig([]byte(`{
+			"CheckBlackList": true,
+			"CheckContextWords": true,
+			"ContextWords": {
+				"*": ["DigiRazor"],
+				"payroll-portal": ["Salary", "wage"]
+			}
+		}`), []string{"test"})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("given you have words configured for the application", func() {
+		It("should reject the words of every application.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?digirazor"})
+			Expect(err).To(BeEquivalentTo(&pwdserv.ContextWordError{Word: "digirazor"}))
+		})
+
+		It("should reject the words of the ApplicationID only.", func() {
+			err := serv.Validate(&pwdserv.Password{ApplicationID: "payroll-portal", NewPassword: "yVHn6?SALARY"})
+			Expect(err).To(MatchError("Password contains the context word 'salary'."))
+
+			Expect(serv.Validate(&pwdserv.Password{ApplicationID: "crm", NewPassword: "yVHn6?SALARY"})).To(Succeed())
+		})
+
+		It("should skip words shorter than the minimum length.", func() {
+			Expect(serv.Validate(&pwdserv.Password{ApplicationID: "payroll-portal", NewPassword: "yVHn6?wag"})).To(Succeed())
+		})
+	})
+
+	Context("given you have terms derived from the ApplicationID and UserContext", func() {
+		It("should reject the terms of the ApplicationID.", func() {
+			err := serv.Validate(&pwdserv.Password{ApplicationID: "payroll-portal", NewPassword: "MyPortal6?"})
+			Expect(err).To(MatchError("Password contains the context word 'portal'."))
+
+			err = serv.Validate(&pwdserv.Password{ApplicationID: "payroll-portal", NewPassword: "PayrollPortal6?"})
+			Expect(err).To(MatchError("Password contains the context word 'payrollportal'."))
+		})
+
+		It("should reject the terms of the UserContext.", func() {
+			user := pwdserv.UserContext{FirstName: "Jo", LastName: "van der Merwe", Email: "jo.merwe@example.co.za", Words: []string{"Pretoria"}}
+
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Merwe6?R@", UserContext: user})
+			Expect(err).To(MatchError("Password contains the context word 'merwe'."))
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "Example6?R@", UserContext: user})
+			Expect(err).To(MatchError("Password contains the context word 'example'."))
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?pretoria", UserContext: user})
+			Expect(errors.Is(err, pwdserv.ErrContextWord)).To(BeTrue())
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?Jo", UserContext: user})).To(Succeed())
+		})
+	})
+
+	Context("given you have a context word that is also black listed", func() {
+		It("should tell the two apart by the validator.", func() {
+			res := serv.ValidateResult(context.Background(), &pwdserv.Password{NewPassword: "yVHn6?test"})
+			Expect(res.Failures[0].Code).To(Equal(pwdserv.ValidatorBlackList))
+
+			res = serv.ValidateResult(context.Background(), &pwdserv.Password{NewPassword: "yVHn6?digirazor"})
+			Expect(res.Failures[0].Code).To(Equal(pwdserv.ValidatorContextWords))
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package pwdserv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Matching of black lists.
+const (
+	// MatchSubstring rejects passwords that contain a word of the list.
+	MatchSubstring = "substring"
+	// MatchExact rejects passwords that are a word of the list, ignoring case.
+	MatchExact = "exact"
+)
+
+// EmbeddedList selects a list embedded in pwdserv for PasswordRules.BlackLists.
+//
+// The "common" list holds the most frequent passwords of the zxcvbn frequency
+// list and the "english" list its frequent English words, see EmbeddedBlackLists.
+// The top 10k and top 100k password lists are not embedded yet; load those with
+// LoadBlackList.
+type EmbeddedList struct {
+	// Name of the list: "common" passwords, "english" words, "calendar" words
+	// (months, days and seasons) or "sports" words.
+	Name string
+	// Version of the list, like "v1". The latest version when empty,
+	// so pin it to keep the same list across upgrades.
+	Version string `json:",omitempty"`
+	// Match is MatchExact, the default, or MatchSubstring. Substring matching
+	// against a list of words rejects far more passwords than it should.
+	Match string `json:",omitempty"`
+}
+
+// EmbeddedBlackLists returns the names and versions of the embedded lists, like "common-v1".
+func EmbeddedBlackLists() []string {
+	names, _ := fs.Glob(lists, "lists/*-v*.txt")
+	for i, name := range names {
+		names[i] = strings.TrimSuffix(strings.TrimPrefix(name, "lists/"), ".txt")
+	}
+
+	return names
+}
+
+// EmbeddedBlackList is a source of the embedded list by name and version,
+// the latest version when empty.
+func EmbeddedBlackList(name, version string) BlackListSource {
+	if version == "" {
+		version = latestVersion(name)
+	}
+
+	return fsSource{lists, "lists/" + name + "-" + version + ".txt"}
+}
+
+// latestVersion returns the highest version of the embedded list.
+func latestVersion(name string) string {
+	var versions []int
+	for _, list := range EmbeddedBlackLists() {
+		if v, ok := strings.CutPrefix(list, name+"-v"); ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				versions = append(versions, n)
+			}
+		}
+	}
+
+	if len(versions) == 0 {
+		return "v1"
+	}
+	sort.Ints(versions)
+
+	return "v" + strconv.Itoa(versions[len(versions)-1])
+}
+
+// wordList is a loaded embedded list.
+type wordList struct {
+	words []string
+	set   map[string]struct{}
+}
+
+// wordLists caches the embedded lists by name and version, as they never change.
+var wordLists sync.Map
+
+func loadWordList(name, version string) (*wordList, error) {
+	if version == "" {
+		version = latestVersion(name)
+	}
+
+	key := name + "-" + version
+	if list, ok := wordLists.Load(key); ok {
+		return list.(*wordList), nil
+	}
+
+	words, err := LoadBlackList(BlackListOptions{}, EmbeddedBlackList(name, version))
+	if err != nil {
+		return nil, fmt.Errorf("Unknown black list '%s'.", key)
+	}
+
+	list := &wordList{words: words, set: make(map[string]struct{}, len(words))}
+	for _, word := range words {
+		list.set[word] = struct{}{}
+	}
+
+	actual, _ := wordLists.LoadOrStore(key, list)
+	return actual.(*wordList), nil
+}
+
+// blackList is an embedded list selected in the configuration.
+type blackList struct {
+	exact bool
+	list  *wordList
+}
+
+// compileBlackLists checks the black list matching and loads the embedded lists of cfg.
+func compileBlackLists(cfg *PasswordRules) error {
+	switch cfg.BlackListMatch {
+	case "", MatchSubstring, MatchExact:
+	default:
+		return fmt.Errorf("Unknown BlackListMatch '%s'.", cfg.BlackListMatch)
+	}
+
+	cfg.blackLists = nil
+	for _, sel := range cfg.BlackLists {
+		if sel.Name == "" {
+			return errors.New("Black lists must have a Name.")
+		}
+
+		switch sel.Match {
+		case "", MatchExact, MatchSubstring:
+		default:
+			return fmt.Errorf("Unknown Match '%s' for black list '%s'.", sel.Match, sel.Name)
+		}
+
+		list, err := loadWordList(sel.Name, sel.Version)
+		if err != nil {
+			return err
+		}
+
+		cfg.blackLists = append(cfg.blackLists, blackList{exact: sel.Match != MatchSubstring, list: list})
+	}
+
+	return nil
+}
+
+// check returns the error for a password matching the list.
+func (b blackList) check(password string) error {
+	if b.exact {
+		if _, ok := b.list.set[strings.ToLower(password)]; ok {
+			return errBlackListed
+		}
+		return nil
+	}
+
+	for _, word := range b.list.words {
+		if containsFold(password, word) {
+			return fmt.Errorf("Password contains black listed word '%s'.", word)
+		}
+	}
+
+	return nil
+}
+
+var errBlackListed = errors.New("Password is black listed.")
@@ -0,0 +1,95 @@
+package pwdserv_test
+
+import (
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Embedded black lists", func() {
+	It("should list the embedded lists with their versions.", func() {
+		Expect(pwdserv.EmbeddedBlackLists()).To(ConsistOf("calendar-v1", "common-v1", "common-v2", "english-v1", "english-v2", "sports-v1"))
+	})
+
+	Context("given you have selected embedded lists in the configuration", func() {
+		var serv *pwdserv.PasswordService
+
+		BeforeEach(func() {
+			serv = pwdserv.New()
+			err := serv.SetConfig([]byte(`{
+				"CheckBlackList": true,
+				"BlackLists": [
+					{"Name": "common", "Version": "v1"},
+					{"Name": "english", "Version": "v1"},
+					{"Name": "calendar", "Match": "substring"}
+				]
+			}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject a password that is exactly a listed word.", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Qwerty123"})).To(BeEquivalentTo(errors.New("Password is black listed.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Sunset"})).To(BeEquivalentTo(errors.New("Password is black listed.")))
+		})
+
+		It("should accept a password that only contains a word of an exact list.", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "qwerty6?R@x"})).To(Succeed())
+		})
+
+		It("should reject a password containing a word of a substring list.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?December"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password contains black listed word 'december'.")))
+		})
+	})
+
+	Context("given you have selected the latest lists", func() {
+		It("should reject the frequent passwords and English words.", func() {
+			serv := pwdserv.New()
+			err := serv.SetConfig([]byte(`{
+				"CheckBlackList": true,
+				"BlackLists": [{"Name": "common"}, {"Name": "english"}]
+			}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Trustno1"})).To(BeEquivalentTo(errors.New("Password is black listed.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Chocolate"})).To(BeEquivalentTo(errors.New("Password is black listed.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "chocolate6?R@x"})).To(Succeed())
+		})
+
+		It("should embed the full frequency lists.", func() {
+			common, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.EmbeddedBlackList("common", ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(common).To(HaveLen(7141))
+
+			english, err := pwdserv.LoadBlackList(pwdserv.BlackListOptions{}, pwdserv.EmbeddedBlackList("english", "v2"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(english).To(HaveLen(29994))
+		})
+	})
+
+	Context("given you match the BlackList exactly", func() {
+		It("should only reject the listed words.", func() {
+			serv := pwdserv.New()
+			Expect(serv.SetConfig([]byte(`{"CheckBlackList": true, "BlackListMatch": "exact"}`), []string{"acme"})).To(Succeed())
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "ACME"})).To(BeEquivalentTo(errors.New("Password is black listed.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "acme6?R@"})).To(Succeed())
+		})
+	})
+
+	Context("given you have selected an unknown list", func() {
+		It("should return an error when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"BlackLists": [{"Name": "common", "Version": "v9"}]}`), nil)
+			Expect(err).To(MatchError("Unknown black list 'common-v9'."))
+
+			err = pwdserv.New().SetConfig([]byte(`{"BlackLists": [{"Name": "common", "Match": "prefix"}]}`), nil)
+			Expect(err).To(MatchError("Unknown Match 'prefix' for black list 'common'."))
+
+			err = pwdserv.New().SetConfig([]byte(`{"BlackListMatch": "prefix"}`), nil)
+			Expect(err).To(MatchError("Unknown BlackListMatch 'prefix'."))
+		})
+	})
+})
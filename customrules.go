@@ -0,0 +1,201 @@
+package pwdserv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// CustomRule is a regular expression rule declared in the configuration:
+//
+//	"CustomRules": [
+//		{"Name": "NoDigitStart", "Pattern": "^[0-9]", "Message": "Password may not start with a digit."},
+//		{"Name": "NoSeason", "Pattern": "(?i)^(summer|winter)\\d{4}", "Message": "Password may not be a season and year."}
+//	]
+//
+// The rules are registered as validators named after the rule.
+type CustomRule struct {
+	// Name of the rule, also the name of its validator.
+	Name string
+	// Pattern is the regular expression, in the syntax of the regexp package.
+	Pattern string
+	// MustMatch is true if the NewPassword must match the Pattern,
+	// false (the default) if it may not match.
+	MustMatch bool
+	// Message is the error returned when the rule fails.
+	Message string
+	// Description is the requirement text for Describe, the Message when empty.
+	Description string
+	// Severity of the rule's failures, SeverityError when empty.
+	Severity Severity
+
+	re *regexp.Regexp
+}
+
+// compileRules checks and compiles the CustomRules and ExprRules of cfg.
+func (z *PasswordService) compileRules(cfg *PasswordRules) error {
+	names := make(map[string]bool)
+
+	if err := z.compileCustomRules(cfg, names); err != nil {
+		return err
+	}
+
+	return z.compileExprRules(cfg, names)
+}
+
+// compileCustomRules checks and compiles the CustomRules of cfg.
+func (z *PasswordService) compileCustomRules(cfg *PasswordRules, names map[string]bool) error {
+	for i := range cfg.CustomRules {
+		rule := &cfg.CustomRules[i]
+
+		if rule.Name == "" {
+			return errors.New("Custom rules must have a Name.")
+		}
+
+		if names[rule.Name] || z.isValidator(rule.Name) {
+			return fmt.Errorf("Custom rule '%s' is already registered.", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Message == "" {
+			return fmt.Errorf("Custom rule '%s' must have a Message.", rule.Name)
+		}
+
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("Custom rule '%s': %s", rule.Name, err)
+		}
+		rule.re = re
+	}
+
+	return nil
+}
+
+// isValidator reports whether name is registered as a validator other than a configured rule.
+func (z *PasswordService) isValidator(name string) bool {
+	if _, ok := z.vl[name]; !ok {
+		return false
+	}
+
+	for _, rule := range z.rules {
+		if rule == name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addRules registers a validator for every custom and expression rule of the
+// active and candidate configurations, and removes the ones no longer configured.
+func (z *PasswordService) addRules() {
+	for _, name := range z.rules {
+		z.remove(name)
+	}
+	z.rules = nil
+
+	for _, cfg := range []*PasswordRules{z.config, z.candidate} {
+		if cfg == nil {
+			continue
+		}
+
+		for _, rule := range cfg.CustomRules {
+			z.addRule(rule.Name)
+		}
+		for _, rule := range cfg.ExprRules {
+			z.addRule(rule.Code)
+		}
+	}
+}
+
+// addRule registers the validator of a configured rule, once.
+func (z *PasswordService) addRule(name string) {
+	for _, rule := range z.rules {
+		if rule == name {
+			return
+		}
+	}
+
+	z.AddContext(name, checkRule(name), Cheap(), Description(describeRule(name)))
+	z.rules = append(z.rules, name)
+}
+
+// checkRule validator evaluates the custom or expression rule by name,
+// whichever the configuration has.
+func checkRule(name string) ValidationContext {
+	custom, expr := checkCustomRule(name), checkExprRule(name)
+
+	return func(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+		if config.customRule(name) != nil {
+			return custom(password, config)
+		}
+
+		return expr(ctx, password, config)
+	}
+}
+
+func describeRule(name string) Describer {
+	custom, expr := describeCustomRule(name), describeExprRule(name)
+
+	return func(locale string, c *PasswordRules) *Requirement {
+		if c.customRule(name) != nil {
+			return custom(locale, c)
+		}
+
+		return expr(locale, c)
+	}
+}
+
+// customRule returns the custom rule by name.
+func (c *PasswordRules) customRule(name string) *CustomRule {
+	for i := range c.CustomRules {
+		if c.CustomRules[i].Name == name {
+			return &c.CustomRules[i]
+		}
+	}
+
+	return nil
+}
+
+// checkCustomRule validator checks the NewPassword against the custom rule
+// by name, if the configuration has the rule.
+func checkCustomRule(name string) Validation {
+	return func(password *Password, config *PasswordRules) (bool, error) {
+		rule := config.customRule(name)
+		if rule == nil {
+			return true, nil
+		}
+
+		re := rule.re
+		if re == nil {
+			// rules that were not loaded with SetConfig
+			var err error
+			if re, err = regexp.Compile(rule.Pattern); err != nil {
+				return false, fmt.Errorf("Custom rule '%s': %s", rule.Name, err)
+			}
+		}
+
+		if re.MatchString(config.normalize(password.NewPassword)) != rule.MustMatch {
+			return false, errors.New(rule.Message)
+		}
+
+		return true, nil
+	}
+}
+
+func describeCustomRule(name string) Describer {
+	return func(_ string, c *PasswordRules) *Requirement {
+		rule := c.customRule(name)
+		if rule == nil {
+			return nil
+		}
+
+		text := rule.Description
+		if text == "" {
+			text = rule.Message
+		}
+
+		return &Requirement{Code: rule.Name, Text: text}
+	}
+}
@@ -0,0 +1,93 @@
+package pwdserv_test
+
+import (
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Custom rules", func() {
+	Context("given you have a service configured with custom rules", func() {
+		serv := pwdserv.New()
+		err := serv.SetConfig([]byte(`{
+			"CheckMinLength": true,
+			"MinLength": 8,
+			"CustomRules": [
+				{"Name": "NoDigitStart", "Pattern": "^[0-9]", "Message": "Password may not start with a digit."},
+				{"Name": "NoSeason", "Pattern": "(?i)^(summer|winter)\\d{4}", "Message": "Password may not be a season and year."},
+				{"Name": "HasAt", "Pattern": "@", "MustMatch": true, "Message": "Password must contain an @.", "Description": "At least one @."}
+			]
+		}`), nil)
+
+		It("should load the rules when calling SetConfig().", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should not return error for a password that passes the rules.", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(Succeed())
+		})
+
+		It("should return the message of a must-not-match rule.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Summer2017@"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password may not be a season and year.")))
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "6yVHn?R@"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password may not start with a digit.")))
+		})
+
+		It("should return the message of a must-match rule.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R!"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password must contain an @.")))
+		})
+
+		It("should describe the rules.", func() {
+			reqs, _ := serv.Describe("en")
+			Expect(reqs).To(ContainElement(pwdserv.Requirement{Code: "HasAt", Text: "At least one @."}))
+		})
+
+		It("should drop the rules that are no longer configured.", func() {
+			serv := pwdserv.New()
+			Expect(serv.SetConfig([]byte(`{"CustomRules": [{"Name": "NoDigitStart", "Pattern": "^[0-9]", "Message": "No digit."}]}`), nil)).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "6yVHn?R@"})).ToNot(Succeed())
+
+			Expect(serv.SetConfig([]byte(`{}`), nil)).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "6yVHn?R@"})).To(Succeed())
+		})
+	})
+
+	Context("given you have invalid custom rules", func() {
+		It("should return an error for an invalid pattern when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"CustomRules": [{"Name": "Bad", "Pattern": "([", "Message": "Bad."}]}`), nil)
+			Expect(err).To(MatchError(ContainSubstring("Custom rule 'Bad'")))
+		})
+
+		It("should return an error for a duplicate or build-in name when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"CustomRules": [
+				{"Name": "A", "Pattern": "a", "Message": "A."},
+				{"Name": "A", "Pattern": "b", "Message": "B."}
+			]}`), nil)
+			Expect(err).To(MatchError("Custom rule 'A' is already registered."))
+
+			err = pwdserv.New().SetConfig([]byte(`{"CustomRules": [{"Name": "CL", "Pattern": "a", "Message": "A."}]}`), nil)
+			Expect(err).To(MatchError("Custom rule 'CL' is already registered."))
+		})
+
+		It("should return an error for a rule without a Message when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"CustomRules": [{"Name": "A", "Pattern": "a"}]}`), nil)
+			Expect(err).To(MatchError("Custom rule 'A' must have a Message."))
+		})
+
+		It("should return an error instead of panicking when calling Validate() after the failed SetConfig().", func() {
+			serv := pwdserv.New()
+			Expect(serv.SetConfig([]byte(`{"CustomRules": [{"Name": "Bad", "Pattern": "([", "Message": "Bad."}]}`), nil)).ToNot(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(MatchError("No configuration loaded."))
+
+			serv = pwdserv.New()
+			Expect(serv.SetConfig([]byte(`{"Normalization": "fold"}`), nil)).ToNot(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(MatchError("No configuration loaded."))
+		})
+	})
+})
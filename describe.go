@@ -0,0 +1,212 @@
+package pwdserv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Requirement is a password requirement, derived from the configuration,
+// that can be shown to end users.
+type Requirement struct {
+	// Code is the name of the validator.
+	Code string
+	// Text is the human readable requirement in the requested locale.
+	Text string
+	// Params are the configuration values used in the Text.
+	Params map[string]interface{} `json:",omitempty"`
+
+	// message is the key of the messages to render the Text with, the Code when empty.
+	message string
+}
+
+// Describer describes the requirement of a validator for the configuration.
+// It returns nil when the validator is switched off.
+//
+// When the Text is left empty it is rendered from the messages registered for the
+// Code with SetMessages, where "{Name}" is replaced with the value of Params["Name"].
+type Describer func(locale string, config *PasswordRules) *Requirement
+
+// Description sets the Describer of a validator, see Describe.
+func Description(d Describer) ValidatorOption {
+	return func(n *validFunc) {
+		n.describe = d
+	}
+}
+
+// DefaultLocale is used when there are no messages for the requested locale.
+const DefaultLocale = "en"
+
+// Message keys of requirements that vary with the configuration, for SetMessages.
+const (
+	// MessageHistoryTime is the CheckHistory requirement in the time HistoryWindow.
+	MessageHistoryTime = ValidatorHistory + "/time"
+	// MessageHistoryAll is the CheckHistory requirement in the all HistoryWindow.
+	MessageHistoryAll = ValidatorHistory + "/all"
+)
+
+var defaultMessages = map[string]string{
+	ValidatorConfirm:           "The confirmation must match the password.",
+	ValidatorLength:            "At least {MinLength} characters.",
+	ValidatorUserID:            "May not contain the UserID/ Username.",
+	ValidatorUppercase:         "At least 1 Capital letter.",
+	ValidatorLowercase:         "At least 1 lower case character.",
+	ValidatorNumeric:           "At least 1 numeric character.",
+	ValidatorSpecialChar:       "At least 1 of the following characters: '{SpecialChar}'.",
+	ValidatorWhiteSpace:        "No spaces.",
+	ValidatorHistory:           "May not be any of your previous {MinHistory} passwords.",
+	MessageHistoryTime:         "May not be a password you used in the last {HistoryDays} day(s).",
+	MessageHistoryAll:          "May not be any of your previous passwords.",
+	ValidatorBlackList:         "May not contain black listed words.",
+	ValidatorPasswordAge:       "May only be changed once every {MinPasswordAge} day(s).",
+	ValidatorContextWords:      "May not contain words related to the application or to you.",
+	ValidatorHistorySimilarity: "May not be a small change of any of your previous {MinHistory} passwords.",
+}
+
+// SetMessages registers the requirement texts for a locale, by Code, or by the
+// message key, like MessageHistoryTime, of requirements that vary with the configuration.
+// Texts for the build-in validators in the DefaultLocale are provided.
+func (z *PasswordService) SetMessages(locale string, messages map[string]string) {
+	if z.messages == nil {
+		z.messages = make(map[string]map[string]string)
+	}
+
+	if z.messages[locale] == nil {
+		z.messages[locale] = make(map[string]string)
+	}
+
+	for code, text := range messages {
+		z.messages[locale][code] = text
+	}
+}
+
+// Describe returns the active requirements, in the order the validators run,
+// for display to end users. The locale is a language tag like "en" or "en-GB";
+// a missing text falls back to the language and then to the DefaultLocale.
+//
+// Validators registered without a Description are not included.
+func (z *PasswordService) Describe(locale string) ([]Requirement, error) {
+	if z.config == nil {
+		return nil, errNoConfig
+	}
+
+	var reqs []Requirement
+	for _, name := range z.order {
+		value := z.vl[name]
+		if value.disabled {
+			continue
+		}
+
+		if req := z.requirement(value, locale, z.config); req != nil {
+			reqs = append(reqs, *req)
+		}
+	}
+
+	return reqs, nil
+}
+
+// requirement describes the validator in locale, nil when it has no Describer
+// or is switched off in the configuration.
+func (z *PasswordService) requirement(value *validFunc, locale string, config *PasswordRules) *Requirement {
+	if value.describe == nil {
+		return nil
+	}
+
+	req := value.describe(locale, config)
+	if req == nil {
+		return nil
+	}
+
+	if req.Text == "" {
+		key := req.Code
+		if req.message != "" {
+			key, req.message = req.message, ""
+		}
+		req.Text = render(z.message(locale, key), req.Params)
+	}
+
+	return req
+}
+
+// message looks up the text for code in locale, its language and the DefaultLocale.
+func (z *PasswordService) message(locale, code string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+
+	for _, l := range []string{locale, lang, DefaultLocale} {
+		if text, ok := z.messages[l][code]; ok {
+			return text
+		}
+	}
+
+	return defaultMessages[code]
+}
+
+func render(text string, params map[string]interface{}) string {
+	if len(params) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+func requirement(active bool, code string, params map[string]interface{}) *Requirement {
+	if active == false {
+		return nil
+	}
+	return &Requirement{Code: code, Params: params}
+}
+
+func describeConfirm(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckConfirm, ValidatorConfirm, nil)
+}
+
+func describeLength(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckMinLength, ValidatorLength, map[string]interface{}{"MinLength": c.MinLength})
+}
+
+func describeUserID(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckUserID, ValidatorUserID, nil)
+}
+
+func describeUppercase(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckUppercase, ValidatorUppercase, nil)
+}
+
+func describeLowercase(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckLowercase, ValidatorLowercase, nil)
+}
+
+func describeNumeric(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckNumeric, ValidatorNumeric, nil)
+}
+
+func describeSpecialChar(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckSpecialChar, ValidatorSpecialChar, map[string]interface{}{"SpecialChar": c.SpecialChar})
+}
+
+func describeWhiteSpace(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckWhiteSpace, ValidatorWhiteSpace, nil)
+}
+
+func describeHistory(_ string, c *PasswordRules) *Requirement {
+	switch {
+	case c.CheckHistory && c.HistoryWindow == HistoryTime:
+		return &Requirement{Code: ValidatorHistory, Params: map[string]interface{}{"HistoryDays": c.HistoryDays}, message: MessageHistoryTime}
+	case c.CheckHistory && c.HistoryWindow == HistoryAll:
+		return &Requirement{Code: ValidatorHistory, message: MessageHistoryAll}
+	}
+
+	return requirement(c.CheckHistory, ValidatorHistory, map[string]interface{}{"MinHistory": c.MinHistory})
+}
+
+func describeBlackList(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckBlackList, ValidatorBlackList, nil)
+}
+
+func describePasswordAge(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckPasswordAge && c.MinPasswordAge > 0, ValidatorPasswordAge, map[string]interface{}{"MinPasswordAge": c.MinPasswordAge})
+}
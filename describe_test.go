@@ -0,0 +1,60 @@
+package pwdserv_test
+
+import (
+	"encoding/json"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Describe", func() {
+	Context("given you have a configured service", func() {
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{
+			"CheckMinLength": true,
+			"MinLength": 8,
+			"CheckUppercase": true,
+			"CheckSpecialChar": true,
+			"SpecialChar": "!@#"
+		}`), nil)
+		serv.Add("NoDigitStart", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+			return true, nil
+		}, pwdserv.Description(func(locale string, config *pwdserv.PasswordRules) *pwdserv.Requirement {
+			return &pwdserv.Requirement{Code: "NoDigitStart", Text: "May not start with a digit."}
+		}))
+		serv.SetMessages("af", map[string]string{"CL": "Ten minste {MinLength} karakters."})
+
+		It("should return the active requirements when calling Describe().", func() {
+			reqs, err := serv.Describe("en")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(Equal([]pwdserv.Requirement{
+				{Code: "CL", Text: "At least 8 characters.", Params: map[string]interface{}{"MinLength": 8}},
+				{Code: "CUC", Text: "At least 1 Capital letter."},
+				{Code: "CSC", Text: "At least 1 of the following characters: '!@#'.", Params: map[string]interface{}{"SpecialChar": "!@#"}},
+				{Code: "NoDigitStart", Text: "May not start with a digit."},
+			}))
+		})
+
+		It("should use the messages of the locale and fall back to the default.", func() {
+			reqs, err := serv.Describe("af-ZA")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs[0].Text).To(Equal("Ten minste 8 karakters."))
+			Expect(reqs[1].Text).To(Equal("At least 1 Capital letter."))
+		})
+
+		It("should serialize the requirements to JSON.", func() {
+			reqs, _ := serv.Describe("en")
+
+			data, err := json.Marshal(reqs[:2])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`[{"Code":"CL","Text":"At least 8 characters.","Params":{"MinLength":8}},{"Code":"CUC","Text":"At least 1 Capital letter."}]`))
+		})
+	})
+
+	It("should return an error if Describe() is called before SetConfig().", func() {
+		_, err := pwdserv.New().Describe("en")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,150 @@
+package pwdserv
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// ruleEngine is the compiled form of the character rules of a PasswordRules,
+// built once by SetConfig.
+type ruleEngine struct {
+	special      [utf8.RuneSelf]bool
+	specialOther string
+}
+
+// charStats counts the character classes of a password.
+type charStats struct {
+	length  int
+	upper   int
+	lower   int
+	numeric int
+	space   int
+	special int
+	symbol  int
+	other   int
+}
+
+// classContext carries the classes of the NewPassword, computed once per Validate
+// call before the validators run, so the build-in validators share a single pass
+// over the password without writing to the Password.
+type classContext struct {
+	context.Context
+	password string
+	engine   *ruleEngine
+	stats    charStats
+}
+
+type classKey struct{}
+
+// Value returns the classContext itself for its key.
+func (c *classContext) Value(key interface{}) interface{} {
+	if key == (classKey{}) {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+// withClasses classifies the NewPassword for the validators run with the returned context.
+func withClasses(ctx context.Context, password *Password, config *PasswordRules) context.Context {
+	if config == nil || config.engine == nil {
+		return ctx
+	}
+
+	return &classContext{
+		Context:  ctx,
+		password: password.NewPassword,
+		engine:   config.engine,
+		stats:    config.engine.classify(password.NewPassword),
+	}
+}
+
+// classesIn returns the classes of the NewPassword from ctx, or classifies the
+// password when ctx has none for the password and rules.
+func classesIn(ctx context.Context, password *Password, config *PasswordRules) charStats {
+	c, ok := ctx.Value(classKey{}).(*classContext)
+	if ok && c.engine == config.engine && c.password == password.NewPassword {
+		return c.stats
+	}
+
+	return config.classes(password)
+}
+
+func compileEngine(c *PasswordRules) *ruleEngine {
+	e := new(ruleEngine)
+
+	for _, r := range c.SpecialChar {
+		if r < utf8.RuneSelf {
+			e.special[r] = true
+		} else {
+			e.specialOther += string(r)
+		}
+	}
+
+	return e
+}
+
+// classify counts the classes of every character of the password in a single pass.
+// Like the regular expressions it replaces, the letters, digits and white space are ASCII only.
+func (e *ruleEngine) classify(password string) charStats {
+	var st charStats
+
+	for _, r := range password {
+		st.length++
+
+		if r >= utf8.RuneSelf {
+			st.other++
+			if e.specialOther != "" && strings.ContainsRune(e.specialOther, r) {
+				st.special++
+			}
+			continue
+		}
+
+		switch {
+		case 'A' <= r && r <= 'Z':
+			st.upper++
+		case 'a' <= r && r <= 'z':
+			st.lower++
+		case '0' <= r && r <= '9':
+			st.numeric++
+		case r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r':
+			st.space++
+		default:
+			st.symbol++
+		}
+
+		if e.special[r] {
+			st.special++
+		}
+	}
+
+	return st
+}
+
+// classes returns the character classes of the NewPassword.
+func (c *PasswordRules) classes(password *Password) charStats {
+	return c.classify(password.NewPassword)
+}
+
+// classify returns the character classes of a password.
+func (c *PasswordRules) classify(password string) charStats {
+	e := c.engine
+	if e == nil {
+		// rules that were not loaded with SetConfig
+		e = compileEngine(c)
+	}
+
+	return e.classify(password)
+}
+
+// containsFold reports whether substr is within s, ignoring case, without allocating.
+func containsFold(s, substr string) bool {
+	n := len(substr)
+	for i := 0; i+n <= len(s); i++ {
+		if strings.EqualFold(s[i:i+n], substr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package pwdserv
+
+import (
+	"context"
+	"math"
+)
+
+// RuleStatus is the outcome of one validator in an Evaluation.
+type RuleStatus struct {
+	// Code is the name of the validator.
+	Code string
+	// Passed is true if the password passed the validator.
+	Passed bool
+	// Severity of the validator.
+	Severity Severity
+	// Message describes the failure.
+	Message string `json:",omitempty"`
+}
+
+// Evaluation is the result of Evaluate.
+type Evaluation struct {
+	// Rules holds the status of every active rule, in the order the validators run.
+	Rules []RuleStatus
+	// Score is the strength of the password, from 0 (very weak) to 4 (very strong).
+	Score int
+	// Valid is true if every rule with SeverityError passed.
+	Valid bool
+}
+
+// Evaluate reports the status of every active rule and the strength of the password,
+// without stopping at the first failure, for password meters that update while the
+// user is typing.
+//
+// Only validators marked as Cheap are run, and without middleware, metrics, audit
+// or rate limits, so it is cheap enough to call on every keystroke. Validate remains
+// the final check.
+func (z *PasswordService) Evaluate(model *Password) *Evaluation {
+	config := z.config
+	if config == nil {
+		config = &PasswordRules{}
+	}
+
+	ctx := withClasses(context.Background(), model, config)
+
+	eval := &Evaluation{Valid: true}
+	for _, name := range z.order {
+		value := z.vl[name]
+		if value.cheap == false || value.disabled {
+			continue
+		}
+
+		// switched off build-in rules are left out
+		if value.describe != nil && value.describe(DefaultLocale, config) == nil {
+			continue
+		}
+
+		status := RuleStatus{Code: name, Severity: z.severity(value)}
+		isvalid, err := value.validation(ctx, model, config)
+		if isvalid {
+			status.Passed = true
+		} else {
+			status.Message = orFailed(name, err).Error()
+			if status.Severity == SeverityError {
+				eval.Valid = false
+			}
+		}
+		eval.Rules = append(eval.Rules, status)
+	}
+
+	eval.Score = score(entropy(config.classify(config.normalize(model.NewPassword))))
+
+	return eval
+}
+
+// entropy estimates the bits of entropy of a password from its length and
+// the size of the character classes it uses.
+func entropy(st charStats) float64 {
+	pool := 0
+	for _, class := range [...]struct {
+		count int
+		size  int
+	}{{st.lower, 26}, {st.upper, 26}, {st.numeric, 10}, {st.symbol + st.space, 33}, {st.other, 100}} {
+		if class.count > 0 {
+			pool += class.size
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(st.length) * math.Log2(float64(pool))
+}
+
+func score(bits float64) int {
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 128:
+		return 3
+	}
+
+	return 4
+}
@@ -0,0 +1,53 @@
+package pwdserv_test
+
+import (
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Evaluate", func() {
+	Context("given you have a configured service", func() {
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{
+			"CheckMinLength": true,
+			"MinLength": 8,
+			"CheckUppercase": true,
+			"CheckNumeric": true
+		}`), nil)
+
+		It("should return the status of every active rule when calling Evaluate().", func() {
+			eval := serv.Evaluate(&pwdserv.Password{NewPassword: "yvhn"})
+
+			Expect(eval.Valid).To(BeFalse())
+			Expect(eval.Rules).To(Equal([]pwdserv.RuleStatus{
+				{Code: "CL", Message: "Passwords must be a minimum of 8 characters."},
+				{Code: "CUC", Message: "Password must contain at least 1 Capital letter."},
+				{Code: "CNC", Message: "Password must contain at least 1 numeric character."},
+			}))
+			Expect(eval.Score).To(Equal(0))
+		})
+
+		It("should return a valid verdict and a higher score for a strong password.", func() {
+			eval := serv.Evaluate(&pwdserv.Password{NewPassword: "yVHn6?R@x8Lq#2"})
+
+			Expect(eval.Valid).To(BeTrue())
+			Expect(eval.Rules).To(HaveLen(3))
+			Expect(eval.Score).To(Equal(3))
+		})
+
+		It("should classify the password again when it changes between calls.", func() {
+			pwd := pwdserv.Password{NewPassword: "yvhn"}
+			Expect(serv.Evaluate(&pwd).Valid).To(BeFalse())
+
+			pwd.NewPassword = "yVHn6?R@"
+			Expect(serv.Evaluate(&pwd).Valid).To(BeTrue())
+		})
+
+		It("should score the password after the normalization.", func() {
+			padded := serv.Evaluate(&pwdserv.Password{NewPassword: "   yVHn6?R@   "})
+			Expect(padded.Score).To(Equal(serv.Evaluate(&pwdserv.Password{NewPassword: "yVHn6?R@"}).Score))
+		})
+	})
+})
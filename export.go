@@ -0,0 +1,300 @@
+package pwdserv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// Kinds of ClientRule.
+const (
+	// KindPattern rules test the new password against the Pattern.
+	KindPattern = "pattern"
+	// KindLength rules test the length of the new password, in UTF-8 bytes, against MinLength.
+	KindLength = "length"
+	// KindConfirm rules compare the new password with the confirmation.
+	KindConfirm = "confirm"
+	// KindUserID rules test that the new password does not contain the UserID, ignoring case.
+	KindUserID = "userID"
+	// KindServer rules can only be checked by the server, like the password history.
+	KindServer = "server"
+)
+
+// ClientRule is a rule in a form that can be checked in the browser.
+type ClientRule struct {
+	// Code is the name of the validator.
+	Code string
+	// Kind is how the rule is checked, one of the Kind constants.
+	Kind string
+	// Pattern is an ECMAScript regular expression for KindPattern rules.
+	Pattern string `json:",omitempty"`
+	// Flags of the Pattern: "u", so it matches characters outside the BMP like the server.
+	Flags string `json:",omitempty"`
+	// MustMatch is true if the Pattern must match, false if it may not match.
+	MustMatch bool `json:",omitempty"`
+	// MinLength for KindLength rules, in UTF-8 bytes like the server counts.
+	MinLength int `json:",omitempty"`
+	// Normalized is true if the rule applies to the new password after the
+	// Normalization of the policy, like the length and custom rules.
+	Normalized bool `json:",omitempty"`
+	// Text is the requirement from Describe.
+	Text string `json:",omitempty"`
+	// ServerOnly is true if the rule can not be checked in the browser.
+	ServerOnly bool `json:",omitempty"`
+}
+
+// ClientPolicy is the active policy in a portable form, for instant feedback in the browser
+// that matches Validate. The cmd/pwdwasm command builds the validators for the browser.
+type ClientPolicy struct {
+	// Normalization is the policy applied before the rules are checked.
+	Normalization string
+	// Rules in the order the validators run.
+	Rules []ClientRule
+	// Schema is a JSON Schema for an object with the NewPassword, covering the
+	// pattern rules that apply to the password as typed, in the Unicode mode of
+	// ECMAScript like the "u" Flags of the rules. JSON Schema counts the length
+	// in characters, so the length rule is left to the ClientRule.
+	Schema json.RawMessage
+}
+
+// Export turns the active configuration into a ClientPolicy, with the texts in locale.
+//
+// Rules that need data the browser does not have, like the history, the black list
+// and custom validators, are marked as ServerOnly, as are the custom rules that can
+// not be translated to ECMAScript.
+func (z *PasswordService) Export(locale string) (*ClientPolicy, error) {
+	reqs, err := z.Describe(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &ClientPolicy{Normalization: z.config.Normalization}
+	if policy.Normalization == "" {
+		policy.Normalization = NormalizeTrim
+	}
+
+	for _, req := range reqs {
+		rule := clientRule(req.Code, z.config)
+		rule.Text = req.Text
+		policy.Rules = append(policy.Rules, rule)
+	}
+
+	policy.Schema, err = schema(policy.Rules, policy.Normalization)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func clientRule(code string, c *PasswordRules) ClientRule {
+	switch code {
+	case ValidatorConfirm:
+		return ClientRule{Code: code, Kind: KindConfirm}
+	case ValidatorLength:
+		return ClientRule{Code: code, Kind: KindLength, MinLength: c.MinLength, Normalized: true}
+	case ValidatorUserID:
+		return ClientRule{Code: code, Kind: KindUserID}
+	case ValidatorUppercase:
+		return ClientRule{Code: code, Kind: KindPattern, Pattern: "[A-Z]", Flags: "u", MustMatch: true}
+	case ValidatorLowercase:
+		return ClientRule{Code: code, Kind: KindPattern, Pattern: "[a-z]", Flags: "u", MustMatch: true}
+	case ValidatorNumeric:
+		return ClientRule{Code: code, Kind: KindPattern, Pattern: "[0-9]", Flags: "u", MustMatch: true}
+	case ValidatorSpecialChar:
+		return ClientRule{Code: code, Kind: KindPattern, Pattern: "[" + escapeClass(c.SpecialChar) + "]", Flags: "u", MustMatch: true}
+	case ValidatorWhiteSpace:
+		// the white space of the server, \s of ECMAScript has Unicode spaces too
+		return ClientRule{Code: code, Kind: KindPattern, Pattern: `[\t\n\f\r ]`, Flags: "u"}
+	}
+
+	if rule := c.customRule(code); rule != nil {
+		if pattern, ok := ecmaPattern(rule.Pattern); ok {
+			return ClientRule{Code: code, Kind: KindPattern, Pattern: pattern, Flags: "u", MustMatch: rule.MustMatch, Normalized: true}
+		}
+	}
+
+	return ClientRule{Code: code, Kind: KindServer, ServerOnly: true}
+}
+
+// escapeClass escapes the characters with a meaning inside an ECMAScript character class.
+func escapeClass(chars string) string {
+	var b strings.Builder
+	for _, r := range chars {
+		if strings.ContainsRune(`\]^-[`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func schema(rules []ClientRule, normalization string) (json.RawMessage, error) {
+	password := map[string]interface{}{"type": "string"}
+
+	var all []interface{}
+	for _, rule := range rules {
+		// JSON Schema can not normalize the password first
+		if rule.Normalized && normalization != NormalizeNone {
+			continue
+		}
+
+		switch {
+		case rule.Kind == KindPattern && rule.MustMatch:
+			all = append(all, map[string]interface{}{"pattern": rule.Pattern})
+		case rule.Kind == KindPattern:
+			all = append(all, map[string]interface{}{"not": map[string]interface{}{"pattern": rule.Pattern}})
+		}
+	}
+	if len(all) > 0 {
+		password["allOf"] = all
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": map[string]interface{}{"NewPassword": password},
+		"required":   []string{"NewPassword"},
+	})
+	if err != nil {
+		return nil, errors.New("Unable to build the JSON Schema.")
+	}
+
+	return data, nil
+}
+
+// ecmaPattern translates a regular expression of the regexp package to an ECMAScript
+// pattern that matches the same with the "u" flag, which matches code points like
+// the server instead of the halves of surrogate pairs. It reports false for
+// expressions that can not be translated.
+func ecmaPattern(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	if !writeECMA(&b, re) {
+		return "", false
+	}
+
+	return b.String(), true
+}
+
+func writeECMA(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		b.WriteString(`[^\s\S]`)
+	case syntax.OpEmptyMatch:
+		b.WriteString(`(?:)`)
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && unicode.SimpleFold(r) != r {
+				// ECMAScript has no inline flags, so fold with a class
+				b.WriteByte('[')
+				for f := r; ; {
+					writeECMARune(b, f)
+					if f = unicode.SimpleFold(f); f == r {
+						break
+					}
+				}
+				b.WriteByte(']')
+			} else {
+				writeECMARune(b, r)
+			}
+		}
+	case syntax.OpCharClass:
+		b.WriteByte('[')
+		for i := 0; i < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			writeECMARune(b, lo)
+			if hi > lo {
+				b.WriteByte('-')
+				writeECMARune(b, hi)
+			}
+		}
+		b.WriteByte(']')
+	case syntax.OpAnyCharNotNL:
+		b.WriteString(`[^\n]`)
+	case syntax.OpAnyChar:
+		b.WriteString(`[\s\S]`)
+	case syntax.OpBeginLine:
+		b.WriteString(`(?:^|(?<=\n))`)
+	case syntax.OpEndLine:
+		b.WriteString(`(?:$|(?=\n))`)
+	case syntax.OpBeginText:
+		b.WriteByte('^')
+	case syntax.OpEndText:
+		b.WriteByte('$')
+	case syntax.OpWordBoundary:
+		b.WriteString(`\b`)
+	case syntax.OpNoWordBoundary:
+		b.WriteString(`\B`)
+	case syntax.OpCapture:
+		b.WriteByte('(')
+		if !writeECMA(b, re.Sub[0]) {
+			return false
+		}
+		b.WriteByte(')')
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		b.WriteString(`(?:`)
+		if !writeECMA(b, re.Sub[0]) {
+			return false
+		}
+		b.WriteByte(')')
+		switch {
+		case re.Op == syntax.OpStar:
+			b.WriteByte('*')
+		case re.Op == syntax.OpPlus:
+			b.WriteByte('+')
+		case re.Op == syntax.OpQuest:
+			b.WriteByte('?')
+		case re.Max == -1:
+			fmt.Fprintf(b, "{%d,}", re.Min)
+		default:
+			fmt.Fprintf(b, "{%d,%d}", re.Min, re.Max)
+		}
+		if re.Flags&syntax.NonGreedy != 0 {
+			b.WriteByte('?')
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeECMA(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		b.WriteString(`(?:`)
+		for i, sub := range re.Sub {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			if !writeECMA(b, sub) {
+				return false
+			}
+		}
+		b.WriteByte(')')
+	default:
+		return false
+	}
+
+	return true
+}
+
+// writeECMARune writes a character escaped, unless it is an ASCII letter or digit.
+func writeECMARune(b *strings.Builder, r rune) {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		b.WriteRune(r)
+	case r < 0x80:
+		fmt.Fprintf(b, `\x%02X`, r)
+	case r <= 0xFFFF:
+		fmt.Fprintf(b, `\u%04X`, r)
+	default:
+		fmt.Fprintf(b, `\u{%X}`, r)
+	}
+}
@@ -0,0 +1,110 @@
+package pwdserv_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Export", func() {
+	Context("given you have a configured service", func() {
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{
+			"CheckMinLength": true,
+			"MinLength": 8,
+			"CheckUppercase": true,
+			"CheckSpecialChar": true,
+			"SpecialChar": "!-]",
+			"CheckWhiteSpace": true,
+			"CheckHistory": true,
+			"MinHistory": 3
+		}`), nil)
+
+		It("should export the rules and mark the server-only checks.", func() {
+			policy, err := serv.Export("en")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Normalization).To(Equal("trim"))
+			Expect(policy.Rules).To(Equal([]pwdserv.ClientRule{
+				{Code: "CL", Kind: "length", MinLength: 8, Normalized: true, Text: "At least 8 characters."},
+				{Code: "CUC", Kind: "pattern", Pattern: "[A-Z]", Flags: "u", MustMatch: true, Text: "At least 1 Capital letter."},
+				{Code: "CSC", Kind: "pattern", Pattern: `[!\-\]]`, Flags: "u", MustMatch: true, Text: "At least 1 of the following characters: '!-]'."},
+				{Code: "CWS", Kind: "pattern", Pattern: `[\t\n\f\r ]`, Flags: "u", Text: "No spaces."},
+				{Code: "CH", Kind: "server", ServerOnly: true, Text: "May not be any of your previous 3 passwords."},
+			}))
+		})
+
+		It("should export a JSON Schema for the pattern rules.", func() {
+			policy, err := serv.Export("en")
+			Expect(err).ToNot(HaveOccurred())
+
+			var schema map[string]interface{}
+			Expect(json.Unmarshal(policy.Schema, &schema)).To(Succeed())
+
+			password := schema["properties"].(map[string]interface{})["NewPassword"].(map[string]interface{})
+			Expect(password).ToNot(HaveKey("minLength"))
+			Expect(password["allOf"]).To(ContainElement(map[string]interface{}{"pattern": "[A-Z]"}))
+			Expect(password["allOf"]).To(ContainElement(map[string]interface{}{"not": map[string]interface{}{"pattern": `[\t\n\f\r ]`}}))
+		})
+	})
+
+	Context("given you have custom rules", func() {
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{
+			"CustomRules": [
+				{"Name": "NoSeason", "Pattern": "(?i)^summer\\d{2}", "Message": "No seasons."},
+				{"Name": "NoLetter", "Pattern": "^\\pL", "Message": "May not start with a letter."},
+				{"Name": "Digits", "Pattern": "[0-9].*?[0-9]", "MustMatch": true, "Message": "At least 2 digits."},
+				{"Name": "NoEmoji", "Pattern": "[😀-🙏]", "Message": "No emoji."},
+				{"Name": "Single", "Pattern": "^.$", "Message": "May not be a single character."}
+			]
+		}`), nil)
+		serv.Add("Breached", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+			return true, nil
+		}, pwdserv.Description(func(string, *pwdserv.PasswordRules) *pwdserv.Requirement {
+			return &pwdserv.Requirement{Code: "Breached", Text: "May not be breached."}
+		}))
+
+		It("should translate the patterns to ECMAScript or mark them server-only.", func() {
+			policy, err := serv.Export("en")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Rules).To(HaveLen(6))
+
+			Expect(policy.Rules[0].Pattern).To(Equal(`^[Ss\u017F][Uu][Mm][Mm][Ee][Rr](?:[0-9]){2,2}`))
+			Expect(policy.Rules[0].Flags).To(Equal("u"))
+			Expect(policy.Rules[0].Normalized).To(BeTrue())
+			Expect(policy.Rules[1].Pattern).To(HavePrefix(`^[A-Za-z\u00AA\u00B5`))
+			Expect(policy.Rules[1].Pattern).To(ContainSubstring(`\u{10400}-`))
+			Expect(policy.Rules[2].Pattern).To(Equal(`[0-9](?:[^\n])*?[0-9]`))
+			Expect(policy.Rules[5]).To(Equal(pwdserv.ClientRule{Code: "Breached", Kind: "server", ServerOnly: true, Text: "May not be breached."}))
+		})
+
+		It("should match characters outside the BMP as one character, like the server.", func() {
+			policy, err := serv.Export("en")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy.Rules[3]).To(Equal(pwdserv.ClientRule{Code: "NoEmoji", Kind: "pattern", Pattern: `[\u{1F600}-\u{1F64F}]`, Flags: "u", Normalized: true, Text: "No emoji."}))
+			Expect(policy.Rules[4].Pattern).To(Equal(`^[^\n]$`))
+			Expect(policy.Rules[4].Flags).To(Equal("u"))
+
+			// the server takes the emoji as a single character, as the browser does with the u flag
+			single := pwdserv.New()
+			single.SetFailFast(false)
+			Expect(single.SetConfig([]byte(`{"CustomRules": [
+				{"Name": "NoEmoji", "Pattern": "[😀-🙏]", "Message": "No emoji."},
+				{"Name": "Single", "Pattern": "^.$", "Message": "May not be a single character."}
+			]}`), nil)).To(Succeed())
+
+			err = single.Validate(&pwdserv.Password{NewPassword: "😀"})
+			Expect(err).To(Equal(pwdserv.ValidationErrors{errors.New("No emoji."), errors.New("May not be a single character.")}))
+		})
+
+		It("should leave the normalized rules out of the JSON Schema.", func() {
+			policy, err := serv.Export("en")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(policy.Schema)).ToNot(ContainSubstring("allOf"))
+		})
+	})
+})
@@ -0,0 +1,197 @@
+package pwdserv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Features are the properties of a password that expression rules are evaluated over.
+type Features struct {
+	// Length is the number of characters.
+	Length int
+	// Upper is the number of upper-case ASCII letters.
+	Upper int
+	// Lower is the number of lower-case ASCII letters.
+	Lower int
+	// Numeric is the number of digits.
+	Numeric int
+	// Special is the number of characters from the configured SpecialChar.
+	Special int
+	// Symbol is the number of other ASCII characters, like punctuation.
+	Symbol int
+	// Space is the number of white space characters.
+	Space int
+	// Other is the number of non-ASCII characters.
+	Other int
+	// Classes is the number of classes used of upper-case, lower-case, numeric
+	// and symbols (ASCII or not).
+	Classes int
+	// Entropy is the estimated bits of entropy.
+	Entropy float64
+	// UserID of the user.
+	UserID string
+	// ApplicationID of the application.
+	ApplicationID string
+	// HistorySize is the number of historical passwords supplied.
+	HistorySize int
+}
+
+// PasswordFeatures computes the Features of the NewPassword.
+func PasswordFeatures(password *Password, config *PasswordRules) Features {
+	return features(config.classes(password), password)
+}
+
+// features computes the Features from the classes of the NewPassword.
+func features(st charStats, password *Password) Features {
+	f := Features{
+		Length:        st.length,
+		Upper:         st.upper,
+		Lower:         st.lower,
+		Numeric:       st.numeric,
+		Special:       st.special,
+		Symbol:        st.symbol,
+		Space:         st.space,
+		Other:         st.other,
+		Entropy:       entropy(st),
+		UserID:        password.UserID,
+		ApplicationID: password.ApplicationID,
+		HistorySize:   len(password.PasswordHistory) + len(password.History),
+	}
+
+	for _, n := range []int{st.upper, st.lower, st.numeric, st.symbol + st.other} {
+		if n > 0 {
+			f.Classes++
+		}
+	}
+
+	return f
+}
+
+// ExprEngine compiles the expressions of ExprRules, see SetExprEngine.
+// The celrules package provides an engine for the Common Expression Language.
+type ExprEngine interface {
+	// Compile type-checks the expression, which must evaluate to a bool.
+	Compile(expr string) (ExprProgram, error)
+}
+
+// ExprProgram is a compiled expression.
+type ExprProgram interface {
+	// Eval returns true if the password with the features passes the rule.
+	Eval(f Features) (bool, error)
+}
+
+// ExprRule is an expression rule declared in the configuration, evaluated over
+// the Features of the password. The expression must be true for the password to pass:
+//
+//	"ExprRules": [
+//		{"Code": "Complexity", "Expr": "length >= 16 || classes >= 3",
+//		 "Message": "Password must use 3 of upper-case, lower-case, numeric and special characters, or be at least 16 characters."}
+//	]
+//
+// The rules are registered as validators named after the Code.
+type ExprRule struct {
+	// Code of the rule, also the name of its validator.
+	Code string
+	// Expr is the expression in the language of the ExprEngine.
+	Expr string
+	// Message is the error returned when the rule fails.
+	Message string
+	// Description is the requirement text for Describe, the Message when empty.
+	Description string
+	// Severity of the rule's failures, SeverityError when empty.
+	Severity Severity
+
+	prg ExprProgram
+}
+
+// SetExprEngine sets the engine that compiles ExprRules. It must be set before
+// SetConfig is called with a configuration that has ExprRules.
+func (z *PasswordService) SetExprEngine(engine ExprEngine) {
+	z.expr = engine
+}
+
+// compileExprRules checks and compiles the ExprRules of cfg.
+func (z *PasswordService) compileExprRules(cfg *PasswordRules, names map[string]bool) error {
+	if len(cfg.ExprRules) > 0 && z.expr == nil {
+		return errors.New("No expression engine set for the ExprRules.")
+	}
+
+	for i := range cfg.ExprRules {
+		rule := &cfg.ExprRules[i]
+
+		if rule.Code == "" {
+			return errors.New("Expression rules must have a Code.")
+		}
+
+		if names[rule.Code] || z.isValidator(rule.Code) {
+			return fmt.Errorf("Expression rule '%s' is already registered.", rule.Code)
+		}
+		names[rule.Code] = true
+
+		if rule.Message == "" {
+			return fmt.Errorf("Expression rule '%s' must have a Message.", rule.Code)
+		}
+
+		prg, err := z.expr.Compile(rule.Expr)
+		if err != nil {
+			return fmt.Errorf("Expression rule '%s': %s", rule.Code, err)
+		}
+		rule.prg = prg
+	}
+
+	return nil
+}
+
+// exprRule returns the expression rule by code.
+func (c *PasswordRules) exprRule(code string) *ExprRule {
+	for i := range c.ExprRules {
+		if c.ExprRules[i].Code == code {
+			return &c.ExprRules[i]
+		}
+	}
+
+	return nil
+}
+
+// checkExprRule validator evaluates the expression rule by code,
+// if the configuration has the rule.
+func checkExprRule(code string) ValidationContext {
+	return func(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+		rule := config.exprRule(code)
+		if rule == nil {
+			return true, nil
+		}
+
+		if rule.prg == nil {
+			return false, fmt.Errorf("Expression rule '%s' was not loaded with SetConfig.", rule.Code)
+		}
+
+		ok, err := rule.prg.Eval(features(classesIn(ctx, password, config), password))
+		if err != nil {
+			return false, fmt.Errorf("Expression rule '%s': %s", rule.Code, err)
+		}
+
+		if ok == false {
+			return false, errors.New(rule.Message)
+		}
+
+		return true, nil
+	}
+}
+
+func describeExprRule(code string) Describer {
+	return func(_ string, c *PasswordRules) *Requirement {
+		rule := c.exprRule(code)
+		if rule == nil {
+			return nil
+		}
+
+		text := rule.Description
+		if text == "" {
+			text = rule.Message
+		}
+
+		return &Requirement{Code: rule.Code, Text: text}
+	}
+}
@@ -0,0 +1,91 @@
+package pwdserv_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// minLength is a stub expression engine that only knows "length >= N".
+type minLength struct{}
+
+func (minLength) Compile(expr string) (pwdserv.ExprProgram, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(expr, "length >= "))
+	if err != nil {
+		return nil, errors.New("Unknown expression.")
+	}
+
+	return minLengthProgram(n), nil
+}
+
+type minLengthProgram int
+
+func (n minLengthProgram) Eval(f pwdserv.Features) (bool, error) {
+	return f.Length >= int(n), nil
+}
+
+var _ = Describe("Expression rules", func() {
+	Context("given you have a service with an expression engine", func() {
+		var serv *pwdserv.PasswordService
+
+		BeforeEach(func() {
+			serv = pwdserv.New()
+			serv.SetExprEngine(minLength{})
+		})
+
+		It("should evaluate the rules when calling Validate().", func() {
+			err := serv.SetConfig([]byte(`{"ExprRules": [
+				{"Code": "Long", "Expr": "length >= 10", "Message": "Password must be long.", "Description": "At least 10 characters."}
+			]}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@xQ"})).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(BeEquivalentTo(errors.New("Password must be long.")))
+
+			reqs, _ := serv.Describe("en")
+			Expect(reqs).To(ContainElement(pwdserv.Requirement{Code: "Long", Text: "At least 10 characters."}))
+		})
+
+		It("should return the compile error when calling SetConfig().", func() {
+			err := serv.SetConfig([]byte(`{"ExprRules": [{"Code": "Bad", "Expr": "entropy > 1", "Message": "Bad."}]}`), nil)
+			Expect(err).To(MatchError("Expression rule 'Bad': Unknown expression."))
+		})
+
+		It("should return an error for a Code already registered when calling SetConfig().", func() {
+			err := serv.SetConfig([]byte(`{
+				"CustomRules": [{"Name": "Long", "Pattern": "a", "Message": "A."}],
+				"ExprRules": [{"Code": "Long", "Expr": "length >= 10", "Message": "Long."}]
+			}`), nil)
+			Expect(err).To(MatchError("Expression rule 'Long' is already registered."))
+		})
+	})
+
+	Context("given you have a service without an expression engine", func() {
+		It("should return an error for expression rules when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"ExprRules": [{"Code": "Long", "Expr": "length >= 10", "Message": "Long."}]}`), nil)
+			Expect(err).To(MatchError("No expression engine set for the ExprRules."))
+		})
+	})
+
+	Context("given you compute the features of a password", func() {
+		It("should count the characters by class.", func() {
+			cfg := &pwdserv.PasswordRules{SpecialChar: "@?"}
+			f := pwdserv.PasswordFeatures(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@", PasswordHistory: []string{"a", "b"}}, cfg)
+
+			Expect(f.Length).To(Equal(8))
+			Expect(f.Upper).To(Equal(3))
+			Expect(f.Lower).To(Equal(2))
+			Expect(f.Numeric).To(Equal(1))
+			Expect(f.Special).To(Equal(2))
+			Expect(f.Classes).To(Equal(4))
+			Expect(f.UserID).To(Equal("ABHW089"))
+			Expect(f.HistorySize).To(Equal(2))
+			Expect(f.Entropy).To(BeNumerically(">", 0))
+		})
+	})
+})
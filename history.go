@@ -0,0 +1,117 @@
+package pwdserv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// History windows for PasswordRules.HistoryWindow.
+//
+// The history of a user is the OldPassword, which is the current password, followed
+// by the previous passwords, newest first: the PasswordHistory, which has no
+// timestamps and must be ordered newest first by the caller, then the History
+// entries, which are ordered by their Changed time whatever order they are given in.
+// Neither should hold the current password.
+const (
+	// HistoryCount checks the OldPassword and the MinHistory-1 newest previous
+	// passwords, so a password may not be one of the last MinHistory passwords.
+	// It is the default.
+	HistoryCount = "count"
+	// HistoryTime checks the OldPassword and the previous passwords that were in use
+	// within the last HistoryDays. A History entry was in use until the next newer
+	// entry was set, or the PasswordChanged time of the current password. Previous
+	// passwords without a timestamp are always checked.
+	HistoryTime = "time"
+	// HistoryAll checks the OldPassword and every previous password.
+	HistoryAll = "all"
+)
+
+// compileHistory checks the history window of cfg, and that the decrypter and hasher
+// needed by its CheckHistorySimilarity are set.
+func compileHistory(cfg *PasswordRules) error {
+	switch cfg.HistoryWindow {
+	case "", HistoryCount, HistoryAll:
+	case HistoryTime:
+		if cfg.HistoryDays <= 0 {
+			return errors.New("HistoryDays must be set for the time HistoryWindow.")
+		}
+	default:
+		return fmt.Errorf("Unknown HistoryWindow '%s'.", cfg.HistoryWindow)
+	}
+
+	if cfg.CheckHistorySimilarity {
+		if cfg.HistoryDistance > 0 && cfg.decrypter == nil {
+			return errNoDecrypter
+		}
+		if cfg.HistoryVariants && cfg.hasher == nil {
+			return errNoHasher
+		}
+	}
+
+	return nil
+}
+
+// historyWindow returns the hashes of the previous passwords in the HistoryWindow,
+// newest first. It does not allocate for a PasswordHistory in the count window.
+func historyWindow(password *Password, config *PasswordRules) []string {
+	if config.HistoryWindow == HistoryTime {
+		return historySince(password, config.now().Add(-days(config.HistoryDays)))
+	}
+
+	hashes := password.PasswordHistory
+	if len(password.History) > 0 {
+		hashes = make([]string, 0, len(password.PasswordHistory)+len(password.History))
+		hashes = append(hashes, password.PasswordHistory...)
+		for _, entry := range sortedHistory(password.History) {
+			hashes = append(hashes, entry.Hash)
+		}
+	}
+
+	if config.HistoryWindow == HistoryAll {
+		return hashes
+	}
+
+	// In his majesty's service, one must always choose the lesser of two weevils
+	return hashes[:max(0, min(config.MinHistory-1, len(hashes)))]
+}
+
+// sortedHistory returns the entries ordered by their Changed time, newest first.
+// Entries without a Changed time are last.
+func sortedHistory(history []HistoryEntry) []HistoryEntry {
+	newer := func(a, b HistoryEntry) bool {
+		if a.Changed.IsZero() || b.Changed.IsZero() {
+			return !a.Changed.IsZero() && b.Changed.IsZero()
+		}
+		return a.Changed.After(b.Changed)
+	}
+
+	if sort.SliceIsSorted(history, func(i, j int) bool { return newer(history[i], history[j]) }) {
+		return history
+	}
+
+	sorted := append([]HistoryEntry(nil), history...)
+	sort.SliceStable(sorted, func(i, j int) bool { return newer(sorted[i], sorted[j]) })
+
+	return sorted
+}
+
+// historySince returns the hashes of the previous passwords that were in use after
+// since, newest first, with the ones without a timestamp.
+func historySince(password *Password, since time.Time) []string {
+	hashes := append([]string(nil), password.PasswordHistory...)
+
+	// the newest previous password was in use until the current one was set
+	retired := password.PasswordChanged
+	for _, entry := range sortedHistory(password.History) {
+		if entry.Changed.IsZero() || retired.IsZero() || !retired.Before(since) {
+			hashes = append(hashes, entry.Hash)
+		}
+		if !entry.Changed.IsZero() {
+			retired = entry.Changed
+		}
+	}
+
+	return hashes
+}
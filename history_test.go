@@ -0,0 +1,119 @@
+package pwdserv_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History window", func() {
+	var (
+		now     = time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+		daysAgo = func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	)
+
+	newService := func(cfg string) *pwdserv.PasswordService {
+		serv := pwdserv.New()
+		serv.SetClock(func() time.Time { return now })
+		Expect(serv.SetConfig([]byte(cfg), nil)).To(Succeed())
+		return serv
+	}
+
+	Context("given you have a count window", func() {
+		var serv *pwdserv.PasswordService
+		var reused = errors.New("You are also not allowed to use any of your previous 3 passwords.")
+
+		BeforeEach(func() {
+			serv = newService(`{"CheckHistory": true, "MinHistory": 3}`)
+		})
+
+		It("should check the current password and the MinHistory-1 newest previous passwords.", func() {
+			Expect(serv.Validate(&pwdserv.Password{OldPassword: "p3", NewPassword: "p3", NewPasswordHash: "h3"})).To(BeEquivalentTo(reused))
+
+			history := []string{"h2", "h1", "h0"}
+			Expect(serv.Validate(&pwdserv.Password{OldPassword: "p3", NewPassword: "p2", NewPasswordHash: "h2", PasswordHistory: history})).To(BeEquivalentTo(reused))
+			Expect(serv.Validate(&pwdserv.Password{OldPassword: "p3", NewPassword: "p1", NewPasswordHash: "h1", PasswordHistory: history})).To(BeEquivalentTo(reused))
+			Expect(serv.Validate(&pwdserv.Password{OldPassword: "p3", NewPassword: "p0", NewPasswordHash: "h0", PasswordHistory: history})).To(Succeed())
+		})
+
+		It("should order the History by the Changed time, whatever the order given.", func() {
+			history := []pwdserv.HistoryEntry{
+				{Hash: "h0", Changed: daysAgo(300)},
+				{Hash: "h2", Changed: daysAgo(100)},
+				{Hash: "h1", Changed: daysAgo(200)},
+			}
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "p1", NewPasswordHash: "h1", History: history})).To(BeEquivalentTo(reused))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "p0", NewPasswordHash: "h0", History: history})).To(Succeed())
+		})
+	})
+
+	Context("given you have a time window", func() {
+		var serv *pwdserv.PasswordService
+		var reused = errors.New("You are not allowed to use a password you used in the last 365 day(s).")
+
+		BeforeEach(func() {
+			serv = newService(`{"CheckHistory": true, "MinHistory": 2, "HistoryWindow": "time", "HistoryDays": 365}`)
+		})
+
+		It("should check the passwords in use within the HistoryDays.", func() {
+			history := []pwdserv.HistoryEntry{
+				{Hash: "h2", Changed: daysAgo(100)},
+				{Hash: "h1", Changed: daysAgo(400)},
+				{Hash: "h0", Changed: daysAgo(800)},
+			}
+			pwd := func(hash string) *pwdserv.Password {
+				return &pwdserv.Password{NewPassword: "p", NewPasswordHash: hash, PasswordChanged: daysAgo(10), History: history}
+			}
+
+			Expect(serv.Validate(pwd("h2"))).To(BeEquivalentTo(reused))
+			// set 400 days ago, but in use until 100 days ago
+			Expect(serv.Validate(pwd("h1"))).To(BeEquivalentTo(reused))
+			Expect(serv.Validate(pwd("h0"))).To(Succeed())
+		})
+
+		It("should always check the previous passwords without a timestamp.", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "p", NewPasswordHash: "h9", PasswordHistory: []string{"h8", "h9"}})).To(BeEquivalentTo(reused))
+		})
+
+		It("should describe the window.", func() {
+			reqs, _ := serv.Describe("en")
+			Expect(reqs).To(ContainElement(pwdserv.Requirement{Code: "CH", Text: "May not be a password you used in the last 365 day(s).", Params: map[string]interface{}{"HistoryDays": 365}}))
+		})
+
+		It("should describe the window with the messages of the locale.", func() {
+			serv.SetMessages("af", map[string]string{pwdserv.MessageHistoryTime: "Mag nie 'n wagwoord van die laaste {HistoryDays} dae wees nie."})
+
+			reqs, _ := serv.Describe("af-ZA")
+			Expect(reqs).To(ContainElement(pwdserv.Requirement{Code: "CH", Text: "Mag nie 'n wagwoord van die laaste 365 dae wees nie.", Params: map[string]interface{}{"HistoryDays": 365}}))
+		})
+	})
+
+	Context("given you reject every previous password", func() {
+		It("should check the whole history.", func() {
+			serv := newService(`{"CheckHistory": true, "MinHistory": 2, "HistoryWindow": "all"}`)
+
+			err := serv.Validate(&pwdserv.Password{NewPassword: "p", NewPasswordHash: "h0", PasswordHistory: []string{"h3", "h2", "h1", "h0"}})
+			Expect(err).To(BeEquivalentTo(errors.New("You are not allowed to use any of your previous passwords.")))
+		})
+
+		It("should describe the window.", func() {
+			serv := newService(`{"CheckHistory": true, "MinHistory": 2, "HistoryWindow": "all"}`)
+			serv.SetMessages("en", map[string]string{pwdserv.MessageHistoryAll: "Never reuse a password."})
+
+			reqs, _ := serv.Describe("en")
+			Expect(reqs).To(ContainElement(pwdserv.Requirement{Code: "CH", Text: "Never reuse a password."}))
+		})
+	})
+
+	Context("given you have an invalid window", func() {
+		It("should return an error when calling SetConfig().", func() {
+			Expect(pwdserv.New().SetConfig([]byte(`{"HistoryWindow": "forever"}`), nil)).To(MatchError("Unknown HistoryWindow 'forever'."))
+			Expect(pwdserv.New().SetConfig([]byte(`{"HistoryWindow": "time"}`), nil)).To(MatchError("HistoryDays must be set for the time HistoryWindow."))
+		})
+	})
+})
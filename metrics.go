@@ -0,0 +1,73 @@
+package pwdserv
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Outcome of a validation as reported to Metrics.
+type Outcome string
+
+const (
+	// OutcomePass the password passed the validation.
+	OutcomePass Outcome = "pass"
+	// OutcomeFail the password failed the validation.
+	OutcomeFail Outcome = "fail"
+	// OutcomeError the validation was cancelled or its deadline expired.
+	OutcomeError Outcome = "error"
+)
+
+// Metrics receives the outcome of validations, see SetMetrics.
+//
+// The prommetrics and otelmetrics packages provide adapters for
+// Prometheus registries and OpenTelemetry meters.
+type Metrics interface {
+	// ObserveValidator is called after every validator has run.
+	ObserveValidator(name string, outcome Outcome, d time.Duration)
+	// ObserveValidate is called once for every call to Validate.
+	ObserveValidate(outcome Outcome, d time.Duration)
+	// ObserveBlackListHit is called when a password is rejected for matching a black list,
+	// not when the black list could not be checked.
+	ObserveBlackListHit()
+}
+
+// SetMetrics instruments Validate with m. Passing nil switches the metrics off.
+func (z *PasswordService) SetMetrics(m Metrics) {
+	z.metrics = m
+}
+
+// call runs a validator and reports its outcome to the metrics.
+func (z *PasswordService) call(ctx context.Context, value *validFunc, model *Password) (bool, error) {
+	if z.metrics == nil {
+		return z.chain(ctx, value, model)
+	}
+
+	// a black list hit is a match, not a list that could not be checked
+	var hit bool
+	if value.blackList {
+		ctx = context.WithValue(ctx, hitKey{}, &hit)
+	}
+
+	start := time.Now()
+	isvalid, err := z.chain(ctx, value, model)
+	outcome := outcomeOf(isvalid, err)
+	z.metrics.ObserveValidator(value.name, outcome, time.Since(start))
+
+	if hit && outcome == OutcomeFail {
+		z.metrics.ObserveBlackListHit()
+	}
+
+	return isvalid, err
+}
+
+func outcomeOf(isvalid bool, err error) Outcome {
+	switch {
+	case isvalid:
+		return OutcomePass
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeError
+	}
+
+	return OutcomeFail
+}
@@ -0,0 +1,84 @@
+package pwdserv_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type memMetrics struct {
+	sync.Mutex
+	validators map[string]pwdserv.Outcome
+	validates  []pwdserv.Outcome
+	blackList  int
+}
+
+func (m *memMetrics) ObserveValidator(name string, outcome pwdserv.Outcome, d time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.validators[name] = outcome
+}
+
+func (m *memMetrics) ObserveValidate(outcome pwdserv.Outcome, d time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.validates = append(m.validates, outcome)
+}
+
+func (m *memMetrics) ObserveBlackListHit() {
+	m.Lock()
+	defer m.Unlock()
+	m.blackList++
+}
+
+var _ = Describe("Metrics", func() {
+	Context("given you have an instrumented service", func() {
+		It("should report every validator, the outcome and black list hits.", func() {
+			m := &memMetrics{validators: make(map[string]pwdserv.Outcome)}
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{"CheckBlackList": true}`), []string{"test"})
+			serv.SetMetrics(m)
+
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Test6?R@"})
+			Expect(err).To(HaveOccurred())
+
+			Expect(m.validators).To(HaveKeyWithValue("CL", pwdserv.OutcomePass))
+			Expect(m.validators).To(HaveKeyWithValue("CBL", pwdserv.OutcomeFail))
+			Expect(m.validates).To(Equal([]pwdserv.Outcome{pwdserv.OutcomeFail}))
+			Expect(m.blackList).To(Equal(1))
+		})
+
+		It("should not count a black list that could not be checked as a hit.", func() {
+			available := true
+			kms := pwdserv.KeyFunc(func() ([][]byte, error) {
+				if !available {
+					return nil, errors.New("KMS unavailable.")
+				}
+				return [][]byte{[]byte("0123456789abcdef")}, nil
+			})
+			index, err := pwdserv.NewBlackListIndex(pwdserv.CachedKeys(kms, 0), []string{"summer"})
+			Expect(err).ToNot(HaveOccurred())
+
+			m := &memMetrics{validators: make(map[string]pwdserv.Outcome)}
+			serv := pwdserv.New()
+			serv.SetBlackListIndex(index)
+			Expect(serv.SetConfig([]byte(`{"CheckBlackList": true}`), nil)).To(Succeed())
+			serv.SetMetrics(m)
+
+			available = false
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "MySummer2024"})).To(MatchError("KMS unavailable."))
+			Expect(m.validators).To(HaveKeyWithValue("CBL", pwdserv.OutcomeFail))
+			Expect(m.blackList).To(BeZero())
+
+			available = true
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "MySummer2024"})).To(HaveOccurred())
+			Expect(m.blackList).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package pwdserv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Middleware wraps a validation, for example to add logging or timing.
+// The name is the name the validator was registered with.
+type Middleware func(name string, next Validation) Validation
+
+// ContextMiddleware wraps a context-aware validation, for middleware that needs
+// the context of the Validate call or passes a derived context to the validators.
+type ContextMiddleware func(name string, next ValidationContext) ValidationContext
+
+// Use adds middleware that wraps every validator, the build-in validators included.
+// The first middleware added is the outermost.
+func (z *PasswordService) Use(middleware ...Middleware) {
+	for _, mw := range middleware {
+		z.mw = append(z.mw, adaptMiddleware(mw))
+	}
+}
+
+// UseContext is the same as Use for context-aware middleware. Middleware added with
+// Use and UseContext wrap the validators in the order they were added.
+func (z *PasswordService) UseContext(middleware ...ContextMiddleware) {
+	z.mw = append(z.mw, middleware...)
+}
+
+// adaptMiddleware passes the context of the call around a Middleware.
+func adaptMiddleware(mw Middleware) ContextMiddleware {
+	return func(name string, next ValidationContext) ValidationContext {
+		return func(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+			inner := func(password *Password, config *PasswordRules) (bool, error) {
+				return next(ctx, password, config)
+			}
+
+			return mw(name, inner)(password, config)
+		}
+	}
+}
+
+// chain runs a validator through the middleware chain.
+func (z *PasswordService) chain(ctx context.Context, value *validFunc, model *Password) (bool, error) {
+	if len(z.mw) == 0 {
+		return value.validation(ctx, model, z.config)
+	}
+
+	next := value.validation
+	for i := len(z.mw) - 1; i >= 0; i-- {
+		next = z.mw[i](value.name, next)
+	}
+
+	return next(ctx, model, z.config)
+}
+
+// Logging middleware logs the outcome and duration of every validation.
+// The password itself is never logged.
+func Logging(logger *slog.Logger) ContextMiddleware {
+	return func(name string, next ValidationContext) ValidationContext {
+		return func(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+			start := time.Now()
+
+			isvalid, err := next(ctx, password, config)
+
+			attrs := []slog.Attr{
+				slog.String("validator", name),
+				slog.String("userID", password.UserID),
+				slog.Bool("valid", isvalid),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			logger.LogAttrs(ctx, slog.LevelInfo, "password validation", attrs...)
+
+			return isvalid, err
+		}
+	}
+}
+
+// Timing middleware reports the duration of every validation to observe.
+func Timing(observe func(name string, d time.Duration)) Middleware {
+	return func(name string, next Validation) Validation {
+		return func(password *Password, config *PasswordRules) (bool, error) {
+			start := time.Now()
+			defer func() {
+				observe(name, time.Since(start))
+			}()
+
+			return next(password, config)
+		}
+	}
+}
+
+// Recover middleware turns a panicking validator into a failed validation.
+func Recover() Middleware {
+	return func(name string, next Validation) Validation {
+		return func(password *Password, config *PasswordRules) (isvalid bool, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					isvalid = false
+					err = fmt.Errorf("Validation '%s' panicked: %v", name, r)
+				}
+			}()
+
+			return next(password, config)
+		}
+	}
+}
+
+// Timeout middleware passes a context with a deadline d to the validation, and fails
+// the validation when the deadline expired before it returned.
+//
+// The validation runs in the goroutine of the caller, so it never outlives Validate.
+// Only context-aware validators are cut short at the deadline; the others run
+// to the end before they fail.
+func Timeout(d time.Duration) ContextMiddleware {
+	return func(name string, next ValidationContext) ValidationContext {
+		return func(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+			runCtx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			isvalid, err := next(runCtx, password, config)
+
+			// a deadline of the caller is not a timeout of the validation
+			if errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+				return false, fmt.Errorf("Validation '%s' timed out after %s.", name, d)
+			}
+
+			return isvalid, err
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package pwdserv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	cfgData := []byte(`{
+		"CheckMinLength": true,
+		"MinLength": 8
+	}`)
+	pwd := pwdserv.Password{NewPassword: "yVHn6?R@"}
+
+	Context("given you have a configured service with middleware", func() {
+		It("should wrap the build-in and custom validators.", func() {
+			var names []string
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig(cfgData, nil)
+			serv.Add("Custom", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				return true, nil
+			})
+			serv.Use(pwdserv.Timing(func(name string, d time.Duration) {
+				names = append(names, name)
+			}))
+
+			err := serv.Validate(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(ContainElement("CL"))
+			Expect(names).To(ContainElement("Custom"))
+		})
+
+		It("should log the outcome without the password.", func() {
+			var buf bytes.Buffer
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig(cfgData, nil)
+			serv.UseContext(pwdserv.Logging(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+			err := serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVH6@"})
+			Expect(err).To(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring(`"validator":"CL"`))
+			Expect(buf.String()).To(ContainSubstring(`"valid":false`))
+			Expect(buf.String()).ToNot(ContainSubstring("yVH6@"))
+		})
+	})
+
+	Context("given you have a service with a misbehaving validator", func() {
+		It("should turn a panic into an error with Recover().", func() {
+			serv := pwdserv.New()
+			serv.Use(pwdserv.Recover())
+			serv.Add("Panic", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				panic("boom")
+			})
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(MatchError("Validation 'Panic' panicked: boom"))
+		})
+
+		It("should pass a panic through Timeout() to Recover().", func() {
+			serv := pwdserv.New()
+			serv.Use(pwdserv.Recover())
+			serv.UseContext(pwdserv.Timeout(time.Second))
+			serv.Add("Panic", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				panic("boom")
+			})
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(MatchError("Validation 'Panic' panicked: boom"))
+		})
+
+		It("should fail a slow validator with Timeout().", func() {
+			serv := pwdserv.New()
+			serv.UseContext(pwdserv.Timeout(10 * time.Millisecond))
+			serv.Add("Slow", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				time.Sleep(100 * time.Millisecond)
+				return false, errors.New("Too late.")
+			})
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(MatchError("Validation 'Slow' timed out after 10ms."))
+		})
+
+		It("should cancel a context-aware validator at the deadline with Timeout().", func() {
+			var stopped time.Duration
+
+			serv := pwdserv.New()
+			serv.UseContext(pwdserv.Timeout(10 * time.Millisecond))
+			serv.AddContext("Slow", func(ctx context.Context, password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				start := time.Now()
+				defer func() { stopped = time.Since(start) }()
+
+				select {
+				case <-ctx.Done():
+					return false, ctx.Err()
+				case <-time.After(time.Second):
+					return true, nil
+				}
+			})
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(MatchError("Validation 'Slow' timed out after 10ms."))
+			Expect(stopped).To(BeNumerically("<", time.Second))
+		})
+	})
+})
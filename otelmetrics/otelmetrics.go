@@ -0,0 +1,92 @@
+// Copyright 2017 DigiRazor (Pty) Ltd. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be found
+// in the LICENSE file.
+
+// Package otelmetrics reports pwdserv validation metrics to an OpenTelemetry meter.
+//
+//	m, err := otelmetrics.New(otel.Meter("pwdserv"))
+//	if err != nil {
+//		return err
+//	}
+//	serv.SetMetrics(m)
+package otelmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Metrics implements pwdserv.Metrics with OpenTelemetry instruments.
+type Metrics struct {
+	validators metric.Int64Counter
+	duration   metric.Float64Histogram
+	validates  metric.Int64Counter
+	latency    metric.Float64Histogram
+	blackList  metric.Int64Counter
+}
+
+// New creates the instruments with meter.
+func New(meter metric.Meter) (*Metrics, error) {
+	var (
+		m   Metrics
+		err error
+	)
+
+	m.validators, err = meter.Int64Counter("pwdserv.validator.runs",
+		metric.WithDescription("Number of validator runs by validator and outcome."))
+	if err != nil {
+		return nil, err
+	}
+
+	m.duration, err = meter.Float64Histogram("pwdserv.validator.duration",
+		metric.WithDescription("Duration of validator runs by validator."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	m.validates, err = meter.Int64Counter("pwdserv.validate.runs",
+		metric.WithDescription("Number of password validations by outcome."))
+	if err != nil {
+		return nil, err
+	}
+
+	m.latency, err = meter.Float64Histogram("pwdserv.validate.duration",
+		metric.WithDescription("Duration of password validations."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	m.blackList, err = meter.Int64Counter("pwdserv.blacklist.hits",
+		metric.WithDescription("Number of passwords rejected by a black list."))
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// ObserveValidator counts the outcome and records the duration of a validator run.
+func (m *Metrics) ObserveValidator(name string, outcome pwdserv.Outcome, d time.Duration) {
+	ctx := context.Background()
+	validator := attribute.String("validator", name)
+
+	m.validators.Add(ctx, 1, metric.WithAttributes(validator, attribute.String("outcome", string(outcome))))
+	m.duration.Record(ctx, d.Seconds(), metric.WithAttributes(validator))
+}
+
+// ObserveValidate counts the outcome and records the duration of a password validation.
+func (m *Metrics) ObserveValidate(outcome pwdserv.Outcome, d time.Duration) {
+	ctx := context.Background()
+
+	m.validates.Add(ctx, 1, metric.WithAttributes(attribute.String("outcome", string(outcome))))
+	m.latency.Record(ctx, d.Seconds())
+}
+
+// ObserveBlackListHit counts a black list hit.
+func (m *Metrics) ObserveBlackListHit() {
+	m.blackList.Add(context.Background(), 1)
+}
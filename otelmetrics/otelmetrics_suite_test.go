@@ -0,0 +1,13 @@
+package otelmetrics_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestOtelmetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Otelmetrics Suite")
+}
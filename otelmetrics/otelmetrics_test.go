@@ -0,0 +1,54 @@
+package otelmetrics_test
+
+import (
+	"context"
+
+	"github.com/DigiRazor/pwdserv"
+	"github.com/DigiRazor/pwdserv/otelmetrics"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func sum(rm metricdata.ResourceMetrics, name string) int64 {
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if data, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == name {
+				for _, dp := range data.DataPoints {
+					total += dp.Value
+				}
+			}
+		}
+	}
+
+	return total
+}
+
+var _ = Describe("Otelmetrics", func() {
+	Context("given you have a service instrumented with a meter", func() {
+		It("should count the outcomes and black list hits when calling Validate().", func() {
+			reader := sdkmetric.NewManualReader()
+			provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			m, err := otelmetrics.New(provider.Meter("pwdserv"))
+			Expect(err).ToNot(HaveOccurred())
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{"CheckMinLength": true, "MinLength": 8, "CheckBlackList": true}`), []string{"test"})
+			serv.SetMetrics(m)
+
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "Test6?R@"})
+
+			var rm metricdata.ResourceMetrics
+			Expect(reader.Collect(context.Background(), &rm)).To(Succeed())
+
+			Expect(sum(rm, "pwdserv.validate.runs")).To(Equal(int64(2)))
+			Expect(sum(rm, "pwdserv.blacklist.hits")).To(Equal(int64(1)))
+			Expect(sum(rm, "pwdserv.validator.runs")).To(BeNumerically(">", 2))
+		})
+	})
+})
@@ -0,0 +1,230 @@
+package pwdserv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ValidationErrors is returned by Validate when fail-fast is switched off,
+// and holds the error of every failed validation in registration order.
+type ValidationErrors []error
+
+// Error joins the descriptions of the failed validations.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, " ")
+}
+
+// Unwrap returns the failed validations for errors.Is and errors.As.
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// SetWorkers sets the number of validators that may run concurrently.
+//
+// Validators marked as Cheap always run first and serially. With n > 1 the
+// remaining validators are run on a pool of n workers, so they must not modify
+// the Password or PasswordRules. The default of 0 runs every validator serially.
+func (z *PasswordService) SetWorkers(n int) {
+	z.workers = n
+}
+
+// SetFailFast sets whether Validate stops at the first failed validation, cancelling
+// the validators still running, or runs every validator and returns ValidationErrors.
+// Fail-fast is the default.
+func (z *PasswordService) SetFailFast(failFast bool) {
+	z.collect = !failFast
+}
+
+type validResult struct {
+	ran     bool
+	isvalid bool
+	err     error
+}
+
+// failure is a failed validation with the name and severity of its validator.
+type failure struct {
+	name     string
+	err      error
+	severity Severity
+}
+
+// blocking reports whether the failure fails the password.
+func (f failure) blocking() bool {
+	return f.severity == SeverityError
+}
+
+// blocking returns whether any of the failures fails the password.
+func blocking(failures []failure) bool {
+	for _, f := range failures {
+		if f.blocking() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run runs the cheap validators first, as a filter for the expensive ones.
+// In fail-fast mode at most one blocking failure is returned. Failures that
+// do not block never stop the validation.
+//
+// The classes of the NewPassword are computed once, before the validators run.
+func (z *PasswordService) run(ctx context.Context, model *Password) ([]failure, error) {
+	ctx = withClasses(ctx, model, z.config)
+
+	failures, err := z.runSerial(ctx, model, true)
+	if err != nil || blocking(failures) {
+		return failures, err
+	}
+
+	var more []failure
+	if z.workers > 1 {
+		more, err = z.runParallel(ctx, model)
+	} else {
+		more, err = z.runSerial(ctx, model, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return append(failures, more...), nil
+}
+
+// runSerial runs either the cheap or the expensive validators one after the other.
+func (z *PasswordService) runSerial(ctx context.Context, model *Password, cheap bool) ([]failure, error) {
+	var failures []failure
+
+	for _, name := range z.order {
+		value := z.vl[name]
+		if value.cheap != cheap || value.disabled {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		isvalid, err := z.call(ctx, value, model)
+		if isvalid == false {
+			f := failure{name, orFailed(name, err), z.severity(value)}
+			failures = append(failures, f)
+			if f.blocking() && z.collect == false {
+				break
+			}
+		}
+	}
+
+	return failures, nil
+}
+
+// runParallel runs the expensive validators on a bounded pool of workers.
+func (z *PasswordService) runParallel(ctx context.Context, model *Password) ([]failure, error) {
+	var jobs []*validFunc
+	for _, name := range z.order {
+		if value := z.vl[name]; value.cheap == false && !value.disabled {
+			jobs = append(jobs, value)
+		}
+	}
+
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]validResult, len(jobs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := min(z.workers, len(jobs)); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if runCtx.Err() != nil {
+					continue
+				}
+
+				isvalid, err := z.call(runCtx, jobs[i], model)
+				results[i] = validResult{ran: true, isvalid: isvalid, err: err}
+				if isvalid == false && z.collect == false && z.severity(jobs[i]) == SeverityError {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// merge in registration order, so the outcome does not depend on scheduling
+	var failures []failure
+	for i, res := range results {
+		if res.ran == false || res.isvalid == true {
+			continue
+		}
+
+		// validators cancelled because another one failed are not failures
+		if z.collect == false && errors.Is(res.err, context.Canceled) {
+			continue
+		}
+
+		f := failure{jobs[i].name, orFailed(jobs[i].name, res.err), z.severity(jobs[i])}
+		failures = append(failures, f)
+		if f.blocking() && z.collect == false {
+			break
+		}
+	}
+
+	return failures, nil
+}
+
+// orFailed makes sure a failed validation has an error to report.
+func orFailed(name string, err error) error {
+	if err == nil {
+		return fmt.Errorf("Validation '%s' failed.", name)
+	}
+
+	return err
+}
+
+// errorOf turns the blocking failures into the error returned by Validate.
+func (z *PasswordService) errorOf(failures []failure, err error) error {
+	if err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	for _, f := range failures {
+		if !f.blocking() {
+			continue
+		}
+
+		if z.collect == false {
+			return f.err
+		}
+		errs = append(errs, f.err)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
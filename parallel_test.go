@@ -0,0 +1,123 @@
+package pwdserv_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func slowValidation(d time.Duration, err error) pwdserv.ValidationContext {
+	return func(ctx context.Context, password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+
+		return err == nil, err
+	}
+}
+
+var _ = Describe("Parallel validators", func() {
+	pwd := pwdserv.Password{NewPassword: "yVHn6?R@"}
+
+	Context("given you have a service with workers", func() {
+		It("should run the validators concurrently when calling Validate().", func() {
+			var running, peak int32
+			all := make(chan struct{})
+
+			// every validator waits until all three are running at the same time
+			barrier := func(ctx context.Context, password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				if n == 3 {
+					close(all)
+				}
+
+				select {
+				case <-all:
+					return true, nil
+				case <-time.After(5 * time.Second):
+					return false, errors.New("The validators did not run concurrently.")
+				}
+			}
+
+			serv := pwdserv.New()
+			serv.SetWorkers(3)
+			serv.AddContext("Slow1", barrier)
+			serv.AddContext("Slow2", barrier)
+			serv.AddContext("Slow3", barrier)
+
+			Expect(serv.Validate(&pwd)).To(Succeed())
+			Expect(atomic.LoadInt32(&peak)).To(BeEquivalentTo(3))
+		})
+
+		It("should return the failures in registration order when fail-fast is off.", func() {
+			serv := pwdserv.New()
+			serv.SetWorkers(2)
+			serv.SetFailFast(false)
+			serv.AddContext("Slow", slowValidation(50*time.Millisecond, errors.New("Slow failed.")))
+			serv.AddContext("Fast", slowValidation(0, errors.New("Fast failed.")))
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(Equal(pwdserv.ValidationErrors{errors.New("Slow failed."), errors.New("Fast failed.")}))
+		})
+
+		It("should cancel the running validators on the first failure in fail-fast mode.", func() {
+			serv := pwdserv.New()
+			serv.SetWorkers(2)
+			serv.AddContext("Slow", slowValidation(time.Minute, nil))
+			serv.AddContext("Fast", slowValidation(0, errors.New("Fast failed.")))
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(MatchError("Fast failed."))
+		})
+
+		It("should not write to the Password from concurrent validators.", func() {
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{}`), nil)
+			serv.SetWorkers(4)
+			for _, name := range []string{"F1", "F2", "F3", "F4"} {
+				serv.Add(name, func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+					return pwdserv.PasswordFeatures(password, config).Length == 8, nil
+				})
+			}
+
+			before := pwd
+			Expect(serv.Validate(&pwd)).To(Succeed())
+			Expect(pwd).To(Equal(before))
+		})
+	})
+
+	Context("given you have a service with cheap validators", func() {
+		It("should not run the expensive validators when a cheap validator fails.", func() {
+			var called int32
+
+			serv := pwdserv.New()
+			serv.SetFailFast(false)
+			serv.AddContext("Expensive", func(ctx context.Context, password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				atomic.AddInt32(&called, 1)
+				return true, nil
+			})
+			serv.Add("Cheap", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				return false, errors.New("Cheap failed.")
+			}, pwdserv.Cheap())
+
+			err := serv.Validate(&pwd)
+			Expect(err).To(Equal(pwdserv.ValidationErrors{errors.New("Cheap failed.")}))
+			Expect(atomic.LoadInt32(&called)).To(BeZero())
+		})
+	})
+})
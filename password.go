@@ -1,6 +1,10 @@
 package pwdserv
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 // Password struct for password validation.
 type Password struct {
@@ -19,11 +23,73 @@ type Password struct {
 	ConfirmPassword string
 
 	// PasswordHistory is a slice containing the history of passwords
-	// previously used by the user.
+	// previously used by the user, newest first, without the current password.
 	PasswordHistory []string
 
 	// NewPasswordHash is used to compare with PasswordHistory.
 	NewPasswordHash string
+
+	// PasswordChanged is the time the current password was set, used
+	// with the CheckPasswordAge config switch.
+	PasswordChanged time.Time
+
+	// History is the timestamped alternative to PasswordHistory, without the current
+	// password. The entries are ordered by their Changed time, newest first.
+	History []HistoryEntry
+
+	// UserContext holds details of the user, used with the CheckContextWords config switch.
+	UserContext UserContext
+}
+
+// redacted replaces secrets in log output and audit records.
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of the password with every password, hash and token
+// field and the personal details of the UserContext replaced, so it is safe to log or audit.
+func (p *Password) Redacted() Password {
+	r := *p
+	r.JWTToken = redact(p.JWTToken)
+	r.OldPassword = redact(p.OldPassword)
+	r.NewPassword = redact(p.NewPassword)
+	r.ConfirmPassword = redact(p.ConfirmPassword)
+	r.NewPasswordHash = redact(p.NewPasswordHash)
+
+	r.PasswordHistory = nil
+	for _, hash := range p.PasswordHistory {
+		r.PasswordHistory = append(r.PasswordHistory, redact(hash))
+	}
+
+	r.History = nil
+	for _, entry := range p.History {
+		r.History = append(r.History, HistoryEntry{Hash: redact(entry.Hash), Changed: entry.Changed})
+	}
+
+	r.UserContext = UserContext{
+		FirstName:    redact(p.UserContext.FirstName),
+		LastName:     redact(p.UserContext.LastName),
+		Email:        redact(p.UserContext.Email),
+		Organisation: redact(p.UserContext.Organisation),
+	}
+	for _, word := range p.UserContext.Words {
+		r.UserContext.Words = append(r.UserContext.Words, redact(word))
+	}
+
+	return r
+}
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// HistoryEntry is a previously used password with the time it was set.
+type HistoryEntry struct {
+	// Hash is compared with the NewPasswordHash.
+	Hash string
+	// Changed is the time the password was set.
+	Changed time.Time
 }
 
 // PasswordRules struct is the configuration options used
@@ -71,9 +137,29 @@ type PasswordRules struct {
 	// the build-in CheckHistory validator.
 	CheckHistory bool
 
-	// MinHistory the number of historical passwords to check.
+	// MinHistory the number of historical passwords to check: the OldPassword and
+	// the MinHistory-1 newest previous passwords, see HistoryCount.
 	MinHistory int
 
+	// HistoryWindow selects the previous passwords that may not be reused:
+	// HistoryCount (the default), HistoryTime or HistoryAll.
+	HistoryWindow string
+
+	// HistoryDays the number of days of the HistoryTime window.
+	HistoryDays int
+
+	// CheckHistorySimilarity is the switch to validate with
+	// the build-in CheckHistorySimilarity validator.
+	CheckHistorySimilarity bool
+
+	// HistoryDistance the maximum number of edits, ignoring case, that a password
+	// within is too similar to a previous one. It needs SetHistoryDecrypter.
+	HistoryDistance int
+
+	// HistoryVariants probes the hashed history with common variants of the
+	// new password, like "Winter2023!" for "Winter2024!". It needs SetHistoryHasher.
+	HistoryVariants bool
+
 	// CheckBlackList is the switch to validate with
 	// the build-in CheckBlackList validator.
 	CheckBlackList bool
@@ -81,6 +167,83 @@ type PasswordRules struct {
 	// BlackList a slice of words not allowed in passwords like: test, password ect
 	BlackList []string
 
+	// BlackListMatch is how the BlackList matches: MatchSubstring, the default, or MatchExact.
+	BlackListMatch string
+
+	// BlackLists selects embedded lists to check along with the BlackList.
+	BlackLists []EmbeddedList
+
+	// CheckContextWords is the switch to validate with
+	// the build-in CheckContextWords validator.
+	CheckContextWords bool
+
+	// ContextWords are words not allowed in the passwords of an application, by
+	// ApplicationID, like the company or product name. The words of "*" apply to all.
+	ContextWords map[string][]string
+
+	// MinContextWordLength drops the context words and derived terms shorter than
+	// MinContextWordLength characters, 4 when not set.
+	MinContextWordLength int
+
+	// CheckPasswordAge is the switch to validate with
+	// the build-in CheckPasswordAge validator and to report
+	// the password status.
+	CheckPasswordAge bool
+
+	// MinPasswordAge the number of days a password must be kept before it may be changed.
+	MinPasswordAge int
+
+	// MaxPasswordAge the number of days after which a password expires.
+	MaxPasswordAge int
+
+	// ExpiryWarning the number of days before expiry that the status changes to StatusWarn.
+	ExpiryWarning int
+
+	// Normalization is the policy applied to the passwords before they are compared
+	// or measured: NormalizeTrim (the default) removes leading and trailing white space,
+	// NormalizeNone uses the passwords as typed.
+	Normalization string
+
+	// CustomRules are regular expression rules declared in the configuration.
+	CustomRules []CustomRule
+
+	// ExprRules are expression rules declared in the configuration, see SetExprEngine.
+	ExprRules []ExprRule
+
+	// Severities overrides the Severity of validators by name, like {"CL": "warning"}
+	// to report a new rule without enforcing it yet. The names must be registered
+	// validators, or rules of the configuration, when the configuration is loaded.
+	Severities map[string]Severity
+
 	// CustomConfig is a holder for custom configuration section
 	CustomConfig json.RawMessage
+
+	clock      func() time.Time
+	engine     *ruleEngine
+	blackLists []blackList
+	decrypter  HistoryDecrypter
+	hasher     HistoryHasher
+	index      *BlackListIndex
+}
+
+// Normalization policies for PasswordRules.Normalization.
+const (
+	NormalizeTrim = "trim"
+	NormalizeNone = "none"
+)
+
+// normalize applies the Normalization policy to a password.
+func (c *PasswordRules) normalize(password string) string {
+	if c.Normalization == NormalizeNone {
+		return password
+	}
+	return strings.TrimSpace(password)
+}
+
+// now returns the current time from the service clock.
+func (c *PasswordRules) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
 }
@@ -0,0 +1,390 @@
+package pwdserv
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// KeyProvider provides the secret keys (pepper) of the keyed hashes of the history
+// and black list index. Keeping the keys apart from the database, like in a KMS,
+// means a leaked database can not be attacked offline.
+type KeyProvider interface {
+	// Keys returns the current key followed by the previous keys, newest first.
+	// New hashes use the current key; verification tries every key, so the keys
+	// can be rotated while hashes of the previous keys are still stored.
+	Keys() ([][]byte, error)
+}
+
+// KeyFunc adapts a function, like a call to a KMS, to a KeyProvider.
+type KeyFunc func() ([][]byte, error)
+
+// Keys calls f.
+func (f KeyFunc) Keys() ([][]byte, error) {
+	return f()
+}
+
+type staticKeys [][]byte
+
+func (k staticKeys) Keys() ([][]byte, error) {
+	return k, nil
+}
+
+// StaticKeys is a KeyProvider of the keys, current first. It stands in for a KMS in tests.
+func StaticKeys(keys ...[]byte) KeyProvider {
+	return staticKeys(keys)
+}
+
+// KeyRefresh is how long the HMACHasher and BlackListIndex use the keys of their
+// KeyProvider before asking it again, unless it was wrapped with CachedKeys.
+const KeyRefresh = 5 * time.Minute
+
+type cachedKeys struct {
+	keys    KeyProvider
+	refresh time.Duration
+
+	mu      sync.Mutex
+	cached  [][]byte
+	expires time.Time
+}
+
+// CachedKeys is a KeyProvider that calls keys again only after the refresh interval,
+// so a KMS is not called for every check. Errors are not cached: the next call tries again.
+func CachedKeys(keys KeyProvider, refresh time.Duration) KeyProvider {
+	if c, ok := keys.(*cachedKeys); ok && c.refresh == refresh {
+		return c
+	}
+
+	return &cachedKeys{keys: keys, refresh: refresh}
+}
+
+func (c *cachedKeys) Keys() ([][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Now().Before(c.expires) {
+		return c.cached, nil
+	}
+
+	keys, err := c.keys.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	c.cached, c.expires = keys, time.Now().Add(c.refresh)
+	return keys, nil
+}
+
+// cached wraps the keys in CachedKeys with the KeyRefresh, unless they are cached already.
+func cached(keys KeyProvider) KeyProvider {
+	if c, ok := keys.(*cachedKeys); ok {
+		return c
+	}
+
+	return CachedKeys(keys, KeyRefresh)
+}
+
+// EnvKeys reads the keys from the environment variable name, as base64 keys
+// separated by commas, current first.
+func EnvKeys(name string) (KeyProvider, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("Environment variable '%s' is not set.", name)
+	}
+
+	return parseKeys(strings.Split(value, ","))
+}
+
+// FileKeys reads the keys from a file with a base64 key per line, current first.
+// Empty lines and lines starting with # are skipped.
+func FileKeys(path string) (KeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return parseKeys(lines)
+}
+
+func parseKeys(encoded []string) (KeyProvider, error) {
+	var keys [][]byte
+	for _, s := range encoded {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid key: %w", err)
+		}
+		if len(key) < 16 {
+			return nil, errors.New("Keys must be at least 16 bytes.")
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("No keys found.")
+	}
+
+	return staticKeys(keys), nil
+}
+
+// currentKey returns the key new hashes are made with.
+func currentKey(keys KeyProvider) ([]byte, error) {
+	all, err := keys.Keys()
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, errors.New("No keys found.")
+	}
+
+	return all[0], nil
+}
+
+func sum(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// HMACHasher hashes passwords with HMAC-SHA256 and a key from a KeyProvider.
+// It is a HistoryHasher: set with SetHistoryHasher, CheckHistory verifies the
+// NewPassword against the history with every key, so rotated keys still match.
+type HMACHasher struct {
+	keys KeyProvider
+}
+
+// NewHMACHasher creates a hasher with the keys, refreshed every KeyRefresh.
+func NewHMACHasher(keys KeyProvider) *HMACHasher {
+	return &HMACHasher{keys: cached(keys)}
+}
+
+// Hash returns the hex HMAC of the password with the current key, to store in the history.
+func (h *HMACHasher) Hash(password string) (string, error) {
+	key, err := currentKey(h.keys)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(sum(key, []byte(password))), nil
+}
+
+// Matches reports whether hash is the HMAC of password with any of the keys.
+// It returns the error of the KeyProvider, so the check fails instead of passing
+// when the keys are unavailable.
+func (h *HMACHasher) Matches(password, hash string) (bool, error) {
+	keys, err := h.keys.Keys()
+	if err != nil {
+		return false, err
+	}
+
+	return signedBy(keys, []byte(password), hash), nil
+}
+
+// BlackListIndex is a compact black list of truncated HMACs of the words, so the
+// words can not be read from, or tested against, the index without the key.
+//
+// Set with SetBlackListIndex, CheckBlackList matches it like the BlackList:
+// exactly with the MatchExact BlackListMatch, else on every substring of the
+// password as long as the shortest word up to as long as the longest word.
+type BlackListIndex struct {
+	keys      KeyProvider
+	minLength int
+	maxLength int
+	sums      []uint64
+}
+
+// NewBlackListIndex indexes the words, lower-cased, with the current key.
+// The keys are refreshed every KeyRefresh.
+func NewBlackListIndex(keys KeyProvider, words []string) (*BlackListIndex, error) {
+	keys = cached(keys)
+	key, err := currentKey(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &BlackListIndex{keys: keys}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+
+		n := utf8.RuneCountInString(word)
+		if x.minLength == 0 || n < x.minLength {
+			x.minLength = n
+		}
+		x.maxLength = max(x.maxLength, n)
+		x.sums = append(x.sums, truncated(key, word))
+	}
+
+	sort.Slice(x.sums, func(i, j int) bool { return x.sums[i] < x.sums[j] })
+
+	return x, nil
+}
+
+func truncated(key []byte, word string) uint64 {
+	return binary.BigEndian.Uint64(sum(key, []byte(word)))
+}
+
+// Len returns the number of words in the index.
+func (x *BlackListIndex) Len() int {
+	return len(x.sums)
+}
+
+// Contains reports whether the word, ignoring case, is in the index with any of the keys.
+func (x *BlackListIndex) Contains(word string) (bool, error) {
+	keys, err := x.keys.Keys()
+	if err != nil {
+		return false, err
+	}
+
+	return newIndexMACs(keys).contains(x, []byte(strings.ToLower(word))), nil
+}
+
+// indexMACs are the HMACs of the keys, reset for every word looked up in an index,
+// and the buffer of their sums.
+type indexMACs struct {
+	macs []hash.Hash
+	buf  []byte
+}
+
+func newIndexMACs(keys [][]byte) *indexMACs {
+	m := &indexMACs{macs: make([]hash.Hash, len(keys)), buf: make([]byte, 0, sha256.Size)}
+	for i, key := range keys {
+		m.macs[i] = hmac.New(sha256.New, key)
+	}
+
+	return m
+}
+
+// contains reports whether the word is in the index with any of the keys.
+func (m *indexMACs) contains(x *BlackListIndex, word []byte) bool {
+	for _, mac := range m.macs {
+		mac.Reset()
+		mac.Write(word)
+		s := binary.BigEndian.Uint64(mac.Sum(m.buf[:0]))
+
+		i := sort.Search(len(x.sums), func(i int) bool { return x.sums[i] >= s })
+		if i < len(x.sums) && x.sums[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match returns the word of the password in the index, if any.
+func (x *BlackListIndex) match(password string, exact bool) (string, bool, error) {
+	keys, err := x.keys.Keys()
+	if err != nil {
+		return "", false, err
+	}
+
+	macs := newIndexMACs(keys)
+	password = strings.ToLower(password)
+	if exact || x.minLength == 0 {
+		return password, macs.contains(x, []byte(password)), nil
+	}
+
+	// the byte offsets of the runes, and the end of the password
+	data := []byte(password)
+	offsets := make([]int, 0, len(data)+1)
+	for i := range password {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(data))
+
+	runes := len(offsets) - 1
+	for i := 0; i < runes; i++ {
+		for j := i + x.minLength; j <= min(runes, i+x.maxLength); j++ {
+			if word := data[offsets[i]:offsets[j]]; macs.contains(x, word) {
+				return string(word), true, nil
+			}
+		}
+	}
+
+	return "", false, nil
+}
+
+var indexMagic = []byte("PWBI\x01")
+
+// WriteTo writes the index in its compact binary form.
+func (x *BlackListIndex) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, 0, len(indexMagic)+16+8*len(x.sums))
+	buf = append(buf, indexMagic...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(x.minLength))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(x.maxLength))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(x.sums)))
+	for _, s := range x.sums {
+		buf = binary.BigEndian.AppendUint64(buf, s)
+	}
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// ReadBlackListIndex reads an index written by WriteTo, to match with the keys.
+// The keys are refreshed every KeyRefresh.
+func ReadBlackListIndex(keys KeyProvider, r io.Reader) (*BlackListIndex, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, indexMagic) || len(data) < len(indexMagic)+16 {
+		return nil, errors.New("Not a black list index.")
+	}
+	data = data[len(indexMagic):]
+
+	x := &BlackListIndex{
+		keys:      cached(keys),
+		minLength: int(binary.BigEndian.Uint32(data)),
+		maxLength: int(binary.BigEndian.Uint32(data[4:])),
+	}
+	n := binary.BigEndian.Uint64(data[8:])
+	data = data[16:]
+	if uint64(len(data)) != 8*n {
+		return nil, errors.New("Black list index is truncated.")
+	}
+
+	x.sums = make([]uint64, n)
+	for i := range x.sums {
+		x.sums[i] = binary.BigEndian.Uint64(data[8*i:])
+	}
+
+	return x, nil
+}
+
+// SetBlackListIndex sets a keyed index that CheckBlackList checks along with the BlackList.
+func (z *PasswordService) SetBlackListIndex(x *BlackListIndex) {
+	z.index = x
+
+	if z.config != nil {
+		z.config.index = x
+	}
+	if z.candidate != nil {
+		z.candidate.index = x
+	}
+}
@@ -0,0 +1,219 @@
+package pwdserv_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pepper", func() {
+	var (
+		oldKey = []byte("0123456789abcdef-old")
+		newKey = []byte("0123456789abcdef-new")
+	)
+
+	Context("given you load the keys", func() {
+		It("should read them from the environment, current first.", func() {
+			os.Setenv("PWDSERV_TEST_KEYS", base64.StdEncoding.EncodeToString(newKey)+", "+base64.StdEncoding.EncodeToString(oldKey))
+			defer os.Unsetenv("PWDSERV_TEST_KEYS")
+
+			kp, err := pwdserv.EnvKeys("PWDSERV_TEST_KEYS")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kp.Keys()).To(Equal([][]byte{newKey, oldKey}))
+
+			_, err = pwdserv.EnvKeys("PWDSERV_TEST_MISSING")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should read them from a file, skipping comments.", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "keys")
+			data := "# rotated 2026-10-01\n" + base64.StdEncoding.EncodeToString(newKey) + "\n\n" + base64.StdEncoding.EncodeToString(oldKey) + "\n"
+			Expect(os.WriteFile(path, []byte(data), 0600)).To(Succeed())
+
+			kp, err := pwdserv.FileKeys(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kp.Keys()).To(Equal([][]byte{newKey, oldKey}))
+		})
+
+		It("should refuse short or invalid keys.", func() {
+			os.Setenv("PWDSERV_TEST_KEYS", base64.StdEncoding.EncodeToString([]byte("short")))
+			defer os.Unsetenv("PWDSERV_TEST_KEYS")
+
+			_, err := pwdserv.EnvKeys("PWDSERV_TEST_KEYS")
+			Expect(err).To(BeEquivalentTo(errors.New("Keys must be at least 16 bytes.")))
+
+			os.Setenv("PWDSERV_TEST_KEYS", "not base64!")
+			_, err = pwdserv.EnvKeys("PWDSERV_TEST_KEYS")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("given you hash the history with a pepper", func() {
+		var (
+			serv    *pwdserv.PasswordService
+			history []string
+		)
+
+		BeforeEach(func() {
+			old := pwdserv.NewHMACHasher(pwdserv.StaticKeys(oldKey))
+			hash, err := old.Hash("yVHn6?R@")
+			Expect(err).ToNot(HaveOccurred())
+			history = []string{hash}
+
+			serv = pwdserv.New()
+			serv.SetHistoryHasher(pwdserv.NewHMACHasher(pwdserv.StaticKeys(newKey, oldKey)))
+			err = serv.SetConfig([]byte(`{"CheckHistory": true, "MinHistory": 3}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject reuse of a password hashed with a previous key.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@", PasswordHistory: history})
+			Expect(err).To(BeEquivalentTo(errors.New("You are also not allowed to use any of your previous 3 passwords.")))
+		})
+
+		It("should accept a new password.", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "3g9m&9W7", PasswordHistory: history})).To(Succeed())
+		})
+
+		It("should not match without the key.", func() {
+			hasher := pwdserv.NewHMACHasher(pwdserv.StaticKeys(newKey))
+			Expect(hasher.Matches("yVHn6?R@", history[0])).To(BeFalse())
+			Expect(hasher.Matches("yVHn6?R@", "not hex")).To(BeFalse())
+		})
+
+		It("should fail the validation when the keys can not be provided.", func() {
+			failing := pwdserv.KeyFunc(func() ([][]byte, error) { return nil, errors.New("KMS unavailable.") })
+			serv := pwdserv.New()
+			serv.SetHistoryHasher(pwdserv.NewHMACHasher(failing))
+			Expect(serv.SetConfig([]byte(`{"CheckHistory": true, "MinHistory": 3}`), nil)).To(Succeed())
+
+			err := serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@", PasswordHistory: history})
+			Expect(err).To(MatchError("Checking the password history failed: KMS unavailable."))
+		})
+	})
+
+	Context("given you load the keys from a KMS", func() {
+		var (
+			calls int
+			fail  bool
+			kms   pwdserv.KeyFunc
+		)
+
+		BeforeEach(func() {
+			calls, fail = 0, false
+			kms = func() ([][]byte, error) {
+				calls++
+				if fail {
+					return nil, errors.New("KMS unavailable.")
+				}
+				return [][]byte{newKey}, nil
+			}
+		})
+
+		It("should only call it again after the refresh interval.", func() {
+			hasher := pwdserv.NewHMACHasher(kms)
+			hash, err := hasher.Hash("yVHn6?R@")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hasher.Matches("yVHn6?R@", hash)).To(BeTrue())
+			Expect(hasher.Matches("3g9m&9W7", hash)).To(BeFalse())
+			Expect(calls).To(Equal(1))
+
+			keys := pwdserv.CachedKeys(kms, time.Millisecond)
+			Expect(keys.Keys()).To(Equal([][]byte{newKey}))
+			time.Sleep(5 * time.Millisecond)
+			Expect(keys.Keys()).To(Equal([][]byte{newKey}))
+			Expect(calls).To(Equal(3))
+		})
+
+		It("should not cache the errors.", func() {
+			keys := pwdserv.CachedKeys(kms, time.Hour)
+
+			fail = true
+			_, err := keys.Keys()
+			Expect(err).To(MatchError("KMS unavailable."))
+
+			fail = false
+			Expect(keys.Keys()).To(Equal([][]byte{newKey}))
+			Expect(keys.Keys()).To(Equal([][]byte{newKey}))
+			Expect(calls).To(Equal(2))
+		})
+	})
+
+	Context("given you index the black list with a pepper", func() {
+		var index *pwdserv.BlackListIndex
+
+		BeforeEach(func() {
+			var err error
+			index, err = pwdserv.NewBlackListIndex(pwdserv.StaticKeys(oldKey), []string{"Password", "summer", "", "test"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should contain the words, ignoring case.", func() {
+			Expect(index.Len()).To(Equal(3))
+			Expect(index.Contains("PASSWORD")).To(BeTrue())
+			Expect(index.Contains("winter")).To(BeFalse())
+		})
+
+		It("should round trip its compact form and match with rotated keys.", func() {
+			var buf bytes.Buffer
+			_, err := index.WriteTo(&buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.Len()).To(Equal(5 + 16 + 3*8))
+
+			rotated, err := pwdserv.ReadBlackListIndex(pwdserv.StaticKeys(newKey, oldKey), &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rotated.Contains("summer")).To(BeTrue())
+
+			_, err = pwdserv.ReadBlackListIndex(pwdserv.StaticKeys(newKey), bytes.NewReader([]byte("words")))
+			Expect(err).To(BeEquivalentTo(errors.New("Not a black list index.")))
+		})
+
+		It("should refuse a truncated index.", func() {
+			var buf bytes.Buffer
+			_, err := index.WriteTo(&buf)
+			Expect(err).ToNot(HaveOccurred())
+
+			data := buf.Bytes()
+			_, err = pwdserv.ReadBlackListIndex(pwdserv.StaticKeys(oldKey), bytes.NewReader(data[:len(data)-1]))
+			Expect(err).To(BeEquivalentTo(errors.New("Black list index is truncated.")))
+		})
+
+		It("should reject passwords containing an indexed word.", func() {
+			serv := pwdserv.New()
+			serv.SetBlackListIndex(index)
+			err := serv.SetConfig([]byte(`{"CheckBlackList": true}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "MySummer2024"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password contains black listed word 'summer'.")))
+			err = serv.Validate(&pwdserv.Password{NewPassword: "Ünïcödé-3g9m&9W7-TEST-pässwörd"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password contains black listed word 'test'.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(Succeed())
+		})
+
+		It("should only reject the exact words with the exact BlackListMatch.", func() {
+			serv := pwdserv.New()
+			serv.SetBlackListIndex(index)
+			err := serv.SetConfig([]byte(`{"CheckBlackList": true, "BlackListMatch": "exact"}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "Summer"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password is black listed.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "MySummer2024"})).To(Succeed())
+		})
+
+		It("should fail when the keys can not be provided.", func() {
+			failing := pwdserv.KeyFunc(func() ([][]byte, error) { return nil, errors.New("KMS unavailable.") })
+			_, err := pwdserv.NewBlackListIndex(failing, []string{"test"})
+			Expect(err).To(BeEquivalentTo(errors.New("KMS unavailable.")))
+		})
+	})
+})
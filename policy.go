@@ -0,0 +1,307 @@
+package pwdserv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Policy is a named configuration that extends a parent policy. The effective
+// PasswordRules of a policy are resolved with a PolicySet:
+//
+//	[
+//		{"Name": "corporate", "Rules": {"CheckMinLength": true, "MinLength": 8, "CheckBlackList": true},
+//		 "BlackList": {"Add": ["password", "acme"]}},
+//		{"Name": "payroll", "Extends": "corporate", "Rules": {"MinLength": 12},
+//		 "BlackList": {"Add": ["payroll"]}, "SpecialChar": {"Remove": "%"}}
+//	]
+type Policy struct {
+	// Name of the policy.
+	Name string
+	// Extends is the name of the parent policy, if any.
+	Extends string `json:",omitempty"`
+	// Rules are the PasswordRules fields set by the policy, overriding the parent.
+	Rules map[string]json.RawMessage `json:",omitempty"`
+	// BlackList adds and removes words from the BlackList of the parent.
+	BlackList ListChange
+	// SpecialChar adds and removes characters from the SpecialChar of the parent.
+	SpecialChar CharChange
+}
+
+// ListChange adds words to and removes words from a list.
+type ListChange struct {
+	Add    []string `json:",omitempty"`
+	Remove []string `json:",omitempty"`
+}
+
+// CharChange adds characters to and removes characters from a string.
+type CharChange struct {
+	Add    string `json:",omitempty"`
+	Remove string `json:",omitempty"`
+}
+
+// ResolvedPolicy are the effective rules of a policy.
+type ResolvedPolicy struct {
+	// Rules are the effective PasswordRules, to load with SetRules.
+	Rules *PasswordRules
+	// Provenance maps the name of every field set along the chain of
+	// policies to the name of the policy that set it last.
+	Provenance map[string]string
+	// BlackList maps every word of the BlackList to the policy that added it.
+	BlackList map[string]string
+	// SpecialChar maps every character of the SpecialChar to the policy that added it.
+	SpecialChar map[string]string
+}
+
+// PolicySet holds named policies to resolve.
+type PolicySet struct {
+	policies map[string]*Policy
+}
+
+// NewPolicySet creates a PolicySet of the policies.
+func NewPolicySet(policies ...Policy) (*PolicySet, error) {
+	set := &PolicySet{policies: make(map[string]*Policy)}
+
+	for i := range policies {
+		p := policies[i]
+		if p.Name == "" {
+			return nil, errors.New("Policies must have a Name.")
+		}
+
+		if _, ok := set.policies[p.Name]; ok {
+			return nil, fmt.Errorf("Policy '%s' is already defined.", p.Name)
+		}
+
+		rules := make(map[string]json.RawMessage, len(p.Rules))
+		for key, value := range p.Rules {
+			field, ok := ruleFields[strings.ToLower(key)]
+			if !ok {
+				return nil, fmt.Errorf("Policy '%s' has unknown rule '%s'.", p.Name, key)
+			}
+			rules[field] = value
+		}
+		p.Rules = rules
+
+		set.policies[p.Name] = &p
+	}
+
+	return set, nil
+}
+
+// ParsePolicies parses a JSON array of policies into a PolicySet.
+func ParsePolicies(data []byte) (*PolicySet, error) {
+	var policies []Policy
+
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, err
+	}
+
+	return NewPolicySet(policies...)
+}
+
+// Resolve returns the effective rules of the named policy: the rules of its
+// parents from the root down, each overridden by the policy that extends it.
+func (s *PolicySet) Resolve(name string) (*ResolvedPolicy, error) {
+	chain, err := s.chain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ResolvedPolicy{
+		Provenance:  make(map[string]string),
+		BlackList:   make(map[string]string),
+		SpecialChar: make(map[string]string),
+	}
+
+	fields := make(map[string]json.RawMessage)
+	var (
+		blackList   []string
+		specialChar string
+	)
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := chain[i]
+
+		for field, value := range p.Rules {
+			fields[field] = value
+			res.Provenance[field] = p.Name
+		}
+
+		// an override of the lists replaces the parent's, before the changes apply
+		if value, ok := p.Rules["BlackList"]; ok {
+			blackList = nil
+			if err := json.Unmarshal(value, &blackList); err != nil {
+				return nil, fmt.Errorf("Policy '%s': %s", p.Name, err)
+			}
+			res.BlackList = make(map[string]string)
+			for _, word := range blackList {
+				res.BlackList[word] = p.Name
+			}
+		}
+		if value, ok := p.Rules["SpecialChar"]; ok {
+			if err := json.Unmarshal(value, &specialChar); err != nil {
+				return nil, fmt.Errorf("Policy '%s': %s", p.Name, err)
+			}
+			res.SpecialChar = make(map[string]string)
+			for _, r := range specialChar {
+				res.SpecialChar[string(r)] = p.Name
+			}
+		}
+
+		blackList = changeList(blackList, p.BlackList, p.Name, res.BlackList)
+		specialChar = changeChars(specialChar, p.SpecialChar, p.Name, res.SpecialChar)
+
+		if len(p.BlackList.Add) > 0 || len(p.BlackList.Remove) > 0 {
+			res.Provenance["BlackList"] = p.Name
+		}
+		if p.SpecialChar.Add != "" || p.SpecialChar.Remove != "" {
+			res.Provenance["SpecialChar"] = p.Name
+		}
+	}
+
+	delete(fields, "BlackList")
+	delete(fields, "SpecialChar")
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&res.Rules); err != nil {
+		return nil, fmt.Errorf("Policy '%s': %s", name, err)
+	}
+
+	res.Rules.BlackList = blackList
+	res.Rules.SpecialChar = specialChar
+
+	return res, nil
+}
+
+// chain returns the named policy followed by its parents.
+func (s *PolicySet) chain(name string) ([]*Policy, error) {
+	var chain []*Policy
+
+	seen := make(map[string]bool)
+	for next := name; next != ""; {
+		p, ok := s.policies[next]
+		if !ok {
+			if len(chain) == 0 {
+				return nil, fmt.Errorf("Policy '%s' is not defined.", next)
+			}
+			return nil, fmt.Errorf("Policy '%s' extends unknown policy '%s'.", chain[len(chain)-1].Name, next)
+		}
+
+		if seen[next] {
+			return nil, cycleError(chain, next)
+		}
+		seen[next] = true
+
+		chain = append(chain, p)
+		next = p.Extends
+	}
+
+	return chain, nil
+}
+
+// cycleError describes the cycle of policies that next closes in the chain.
+func cycleError(chain []*Policy, next string) error {
+	var path []string
+	for i := len(chain) - 1; i >= 0; i-- {
+		path = append([]string{chain[i].Name}, path...)
+		if chain[i].Name == next {
+			break
+		}
+	}
+	path = append(path, next)
+
+	return fmt.Errorf("Policy '%s' has a cycle: %s.", next, strings.Join(path, " -> "))
+}
+
+// changeList adds and removes the words, ignoring case, and records who added them.
+func changeList(list []string, change ListChange, name string, from map[string]string) []string {
+	var out []string
+	for _, word := range list {
+		if !containsWord(change.Remove, word) {
+			out = append(out, word)
+		} else {
+			delete(from, word)
+		}
+	}
+
+	for _, word := range change.Add {
+		if !containsWord(out, word) {
+			out = append(out, word)
+			from[word] = name
+		}
+	}
+
+	return out
+}
+
+func containsWord(list []string, word string) bool {
+	for _, w := range list {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// changeChars adds and removes the characters, and records who added them.
+func changeChars(chars string, change CharChange, name string, from map[string]string) string {
+	var b strings.Builder
+	for _, r := range chars {
+		if !strings.ContainsRune(change.Remove, r) {
+			b.WriteRune(r)
+		} else {
+			delete(from, string(r))
+		}
+	}
+
+	for _, r := range change.Add {
+		if !strings.ContainsRune(b.String(), r) {
+			b.WriteRune(r)
+			from[string(r)] = name
+		}
+	}
+
+	return b.String()
+}
+
+// ruleFields maps the lower-case names of the PasswordRules fields to the names,
+// to match the field names in Rules the way encoding/json does.
+var ruleFields = func() map[string]string {
+	fields := make(map[string]string)
+
+	t := reflect.TypeOf(PasswordRules{})
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			fields[strings.ToLower(f.Name)] = f.Name
+		}
+	}
+
+	return fields
+}()
+
+// SetRules loads the build-in validators and makes rules, like the Rules
+// of a ResolvedPolicy, the active configuration, including its BlackList.
+//
+// The service loads a copy of the rules, so the same rules can be loaded into
+// several services.
+func (z *PasswordService) SetRules(rules *PasswordRules) error {
+	z.addBuildIns()
+
+	cfg := *rules
+	// the compiled rules are kept in the elements
+	cfg.CustomRules = slices.Clone(rules.CustomRules)
+	cfg.ExprRules = slices.Clone(rules.ExprRules)
+
+	return z.load(&cfg)
+}
@@ -0,0 +1,123 @@
+package pwdserv_test
+
+import (
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policy", func() {
+	var policies = []byte(`[
+		{"Name": "corporate",
+		 "Rules": {"CheckMinLength": true, "MinLength": 8, "CheckSpecialChar": true, "SpecialChar": "!@#%", "CheckBlackList": true},
+		 "BlackList": {"Add": ["password", "test"]}},
+		{"Name": "payroll", "Extends": "corporate",
+		 "Rules": {"minLength": 12},
+		 "BlackList": {"Add": ["payroll"], "Remove": ["TEST"]},
+		 "SpecialChar": {"Add": "&", "Remove": "%"}},
+		{"Name": "kiosk", "Extends": "payroll",
+		 "Rules": {"BlackList": ["kiosk"], "CheckSpecialChar": false}}
+	]`)
+
+	Context("given you have a policy extending a base policy", func() {
+		var set *pwdserv.PolicySet
+
+		BeforeEach(func() {
+			var err error
+			set, err = pwdserv.ParsePolicies(policies)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should override the fields and merge the lists when calling Resolve().", func() {
+			res, err := set.Resolve("payroll")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(res.Rules.CheckMinLength).To(BeTrue())
+			Expect(res.Rules.MinLength).To(Equal(12))
+			Expect(res.Rules.SpecialChar).To(Equal("!@#&"))
+			Expect(res.Rules.BlackList).To(Equal([]string{"password", "payroll"}))
+		})
+
+		It("should report where each value came from.", func() {
+			res, _ := set.Resolve("payroll")
+
+			Expect(res.Provenance).To(HaveKeyWithValue("MinLength", "payroll"))
+			Expect(res.Provenance).To(HaveKeyWithValue("CheckMinLength", "corporate"))
+			Expect(res.Provenance).To(HaveKeyWithValue("SpecialChar", "payroll"))
+			Expect(res.BlackList).To(Equal(map[string]string{"password": "corporate", "payroll": "payroll"}))
+			Expect(res.SpecialChar).To(HaveKeyWithValue("&", "payroll"))
+			Expect(res.SpecialChar).To(HaveKeyWithValue("!", "corporate"))
+		})
+
+		It("should replace a list that is overridden.", func() {
+			res, err := set.Resolve("kiosk")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(res.Rules.MinLength).To(Equal(12))
+			Expect(res.Rules.CheckSpecialChar).To(BeFalse())
+			Expect(res.Rules.BlackList).To(Equal([]string{"kiosk"}))
+			Expect(res.BlackList).To(Equal(map[string]string{"kiosk": "kiosk"}))
+		})
+
+		It("should validate with the effective rules after calling SetRules().", func() {
+			res, _ := set.Resolve("payroll")
+
+			before := *res.Rules
+			serv := pwdserv.New()
+			Expect(serv.SetRules(res.Rules)).To(Succeed())
+			Expect(*res.Rules).To(Equal(before))
+			Expect(pwdserv.New().SetRules(res.Rules)).To(Succeed())
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6&R@test"})).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6&R@"})).To(BeEquivalentTo(errors.New("Passwords must be a minimum of 12 characters.")))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6&Payroll"})).To(BeEquivalentTo(errors.New("Password contains black listed word 'payroll'.")))
+		})
+	})
+
+	Context("given you have invalid policies", func() {
+		It("should return an error for an unknown rule.", func() {
+			_, err := pwdserv.ParsePolicies([]byte(`[{"Name": "a", "Rules": {"MinLenght": 8}}]`))
+			Expect(err).To(MatchError("Policy 'a' has unknown rule 'MinLenght'."))
+		})
+
+		It("should return an error for a duplicate policy.", func() {
+			_, err := pwdserv.NewPolicySet(pwdserv.Policy{Name: "a"}, pwdserv.Policy{Name: "a"})
+			Expect(err).To(MatchError("Policy 'a' is already defined."))
+		})
+
+		It("should return an error for an unknown parent or a cycle when calling Resolve().", func() {
+			set, err := pwdserv.NewPolicySet(
+				pwdserv.Policy{Name: "a", Extends: "b"},
+				pwdserv.Policy{Name: "b", Extends: "a"},
+				pwdserv.Policy{Name: "c", Extends: "d"},
+				pwdserv.Policy{Name: "e", Extends: "a"},
+				pwdserv.Policy{Name: "f", Extends: "f"},
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = set.Resolve("a")
+			Expect(err).To(MatchError("Policy 'a' has a cycle: a -> b -> a."))
+
+			_, err = set.Resolve("e")
+			Expect(err).To(MatchError("Policy 'a' has a cycle: a -> b -> a."))
+
+			_, err = set.Resolve("f")
+			Expect(err).To(MatchError("Policy 'f' has a cycle: f -> f."))
+
+			_, err = set.Resolve("c")
+			Expect(err).To(MatchError("Policy 'c' extends unknown policy 'd'."))
+
+			_, err = set.Resolve("x")
+			Expect(err).To(MatchError("Policy 'x' is not defined."))
+		})
+
+		It("should return an error for a value of the wrong type when calling Resolve().", func() {
+			set, _ := pwdserv.ParsePolicies([]byte(`[{"Name": "a", "Rules": {"MinLength": "8"}}]`))
+			_, err := set.Resolve("a")
+			Expect(err).To(MatchError(ContainSubstring("Policy 'a':")))
+		})
+	})
+})
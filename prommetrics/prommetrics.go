@@ -0,0 +1,87 @@
+// Copyright 2017 DigiRazor (Pty) Ltd. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be found
+// in the LICENSE file.
+
+// Package prommetrics reports pwdserv validation metrics to a Prometheus registry.
+//
+//	m, err := prommetrics.New(prometheus.DefaultRegisterer)
+//	if err != nil {
+//		return err
+//	}
+//	serv.SetMetrics(m)
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements pwdserv.Metrics with Prometheus collectors.
+type Metrics struct {
+	validators *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	validates  *prometheus.CounterVec
+	latency    prometheus.Histogram
+	blackList  prometheus.Counter
+}
+
+// New creates the collectors and registers them with reg.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		validators: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pwdserv",
+			Name:      "validator_total",
+			Help:      "Number of validator runs by validator and outcome.",
+		}, []string{"validator", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "pwdserv",
+			Name:      "validator_duration_seconds",
+			Help:      "Duration of validator runs by validator.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}, []string{"validator"}),
+		validates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pwdserv",
+			Name:      "validate_total",
+			Help:      "Number of password validations by outcome.",
+		}, []string{"outcome"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "pwdserv",
+			Name:      "validate_duration_seconds",
+			Help:      "Duration of password validations.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}),
+		blackList: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "pwdserv",
+			Name:      "blacklist_hits_total",
+			Help:      "Number of passwords rejected by a black list.",
+		}),
+	}
+
+	collectors := []prometheus.Collector{m.validators, m.duration, m.validates, m.latency, m.blackList}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ObserveValidator counts the outcome and records the duration of a validator run.
+func (m *Metrics) ObserveValidator(name string, outcome pwdserv.Outcome, d time.Duration) {
+	m.validators.WithLabelValues(name, string(outcome)).Inc()
+	m.duration.WithLabelValues(name).Observe(d.Seconds())
+}
+
+// ObserveValidate counts the outcome and records the duration of a password validation.
+func (m *Metrics) ObserveValidate(outcome pwdserv.Outcome, d time.Duration) {
+	m.validates.WithLabelValues(string(outcome)).Inc()
+	m.latency.Observe(d.Seconds())
+}
+
+// ObserveBlackListHit counts a black list hit.
+func (m *Metrics) ObserveBlackListHit() {
+	m.blackList.Inc()
+}
@@ -0,0 +1,13 @@
+package prommetrics_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPrommetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Prommetrics Suite")
+}
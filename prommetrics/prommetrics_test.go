@@ -0,0 +1,53 @@
+package prommetrics_test
+
+import (
+	"strings"
+
+	"github.com/DigiRazor/pwdserv"
+	"github.com/DigiRazor/pwdserv/prommetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prommetrics", func() {
+	Context("given you have a service instrumented with a registry", func() {
+		It("should count the outcomes and black list hits when calling Validate().", func() {
+			reg := prometheus.NewRegistry()
+			m, err := prommetrics.New(reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{"CheckMinLength": true, "MinLength": 8, "CheckBlackList": true}`), []string{"test"})
+			serv.SetMetrics(m)
+
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "Test6?R@"})
+
+			expected := `
+# HELP pwdserv_blacklist_hits_total Number of passwords rejected by a black list.
+# TYPE pwdserv_blacklist_hits_total counter
+pwdserv_blacklist_hits_total 1
+# HELP pwdserv_validate_total Number of password validations by outcome.
+# TYPE pwdserv_validate_total counter
+pwdserv_validate_total{outcome="fail"} 1
+pwdserv_validate_total{outcome="pass"} 1
+`
+			err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "pwdserv_blacklist_hits_total", "pwdserv_validate_total")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(testutil.CollectAndCount(reg, "pwdserv_validator_total")).To(BeNumerically(">", 0))
+		})
+
+		It("should return an error when the collectors are registered twice.", func() {
+			reg := prometheus.NewRegistry()
+			_, err := prommetrics.New(reg)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = prommetrics.New(reg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
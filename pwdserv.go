@@ -62,19 +62,62 @@
 package pwdserv
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 )
 
 // Validation is a function that can be registered to validate
 // passwords.
 type Validation func(*Password, *PasswordRules) (bool, error)
 
+// ValidationContext is a context-aware validation function, used by
+// validators that need cancellation, deadlines or request-scoped values.
+type ValidationContext func(context.Context, *Password, *PasswordRules) (bool, error)
+
+// AdaptValidation wraps a Validation as a ValidationContext that ignores the context.
+func AdaptValidation(val Validation) ValidationContext {
+	return func(_ context.Context, password *Password, config *PasswordRules) (bool, error) {
+		return val(password, config)
+	}
+}
+
+var (
+	errNoValidators = errors.New("No validators loaded.")
+	errNoConfig     = errors.New("No configuration loaded.")
+)
+
 // PasswordService service to validate user password via
 // configurable validator methods
 type PasswordService struct {
-	vl     map[string]*validFunc
-	config *PasswordRules
+	vl      map[string]*validFunc
+	order   []string
+	config  *PasswordRules
+	clock   func() time.Time
+	workers int
+	collect bool
+	mw      []ContextMiddleware
+	metrics Metrics
+	audit   AuditSink
+	byUser  RateLimiter
+	byApp   RateLimiter
+
+	messages map[string]map[string]string
+	rules    []string
+	expr     ExprEngine
+	buildIns bool
+
+	candidate   *PasswordRules
+	shadow      ShadowSink
+	shadowSlots chan struct{}
+	shadowWG    sync.WaitGroup
+
+	decrypter HistoryDecrypter
+	hasher    HistoryHasher
+	index     *BlackListIndex
 }
 
 // New creates a new initialized PasswordService
@@ -84,19 +127,13 @@ func New() *PasswordService {
 
 // SetConfig loads the build-in validators then parses the configuration data (JSON)
 // and adds the blacklist to the configuration.
+//
+// The build-in validators are only registered by the first call, so the ones
+// removed, replaced or disabled stay that way when the configuration is reloaded.
 func (z *PasswordService) SetConfig(configData []byte, blackList []string) error {
 	var cfg *PasswordRules
 
-	z.Add("CCP", ComfirmPassword)
-	z.Add("CL", CheckLength)
-	z.Add("CUN", CheckUserID)
-	z.Add("CUC", CheckUppercase)
-	z.Add("CLC", CheckLowercase)
-	z.Add("CNC", CheckNumeric)
-	z.Add("CSC", CheckSpecialChar)
-	z.Add("CWS", CheckWhiteSpace)
-	z.Add("CH", CheckHistory)
-	z.Add("CBL", CheckBlackList)
+	z.addBuildIns()
 
 	err := json.Unmarshal(configData, &cfg)
 	if err != nil {
@@ -105,7 +142,51 @@ func (z *PasswordService) SetConfig(configData []byte, blackList []string) error
 
 	cfg.BlackList = blackList
 
+	return z.load(cfg)
+}
+
+// load compiles the rules and makes them the active configuration.
+func (z *PasswordService) load(cfg *PasswordRules) error {
+	if err := z.compile(cfg); err != nil {
+		return err
+	}
+
 	z.config = cfg
+	z.addRules()
+
+	return nil
+}
+
+// compile checks the configuration and compiles its rules.
+func (z *PasswordService) compile(cfg *PasswordRules) error {
+	switch cfg.Normalization {
+	case "", NormalizeTrim, NormalizeNone:
+	default:
+		return fmt.Errorf("Unknown Normalization '%s'.", cfg.Normalization)
+	}
+
+	if err := z.compileRules(cfg); err != nil {
+		return err
+	}
+
+	if err := z.compileSeverities(cfg); err != nil {
+		return err
+	}
+
+	if err := compileBlackLists(cfg); err != nil {
+		return err
+	}
+
+	cfg.clock = z.clock
+	cfg.decrypter = z.decrypter
+	cfg.hasher = z.hasher
+	cfg.index = z.index
+
+	if err := compileHistory(cfg); err != nil {
+		return err
+	}
+
+	cfg.engine = compileEngine(cfg)
 
 	return nil
 }
@@ -115,25 +196,30 @@ func (z *PasswordService) SetConfig(configData []byte, blackList []string) error
 //
 // The returning error has the description of the validation that failed.
 // If the password is valid the returning error will be nil.
+// When fail-fast is switched off with SetFailFast the returning error is
+// a ValidationErrors with every failed validation. Validations with a Severity
+// other than SeverityError never fail the password, see ValidateResult.
 func (z *PasswordService) Validate(model *Password) error {
+	return z.ValidateContext(context.Background(), model)
+}
 
-	if len(z.vl) == 0 {
-		return errors.New("No validators loaded.")
-	}
-
-	for _, value := range z.vl {
-		validation := value.validation
-		isvalid, err := validation(model, z.config)
-		if isvalid == false {
-			return err
-		}
-	}
-
-	return nil
+// ValidateContext is the same as Validate, but passes ctx to the validators.
+//
+// Validation stops with the context error when ctx is cancelled or its deadline expires.
+func (z *PasswordService) ValidateContext(ctx context.Context, model *Password) error {
+	_, err := z.validate(ctx, model)
+	return err
 }
 
 // Add registers a new validator to be used in the validation of the new password.
-func (z *PasswordService) Add(name string, val Validation) {
+//
+// Validators run in the order they were first registered.
+func (z *PasswordService) Add(name string, val Validation, opts ...ValidatorOption) {
+	z.AddContext(name, AdaptValidation(val), opts...)
+}
+
+// AddContext registers a new context-aware validator to be used in the validation of the new password.
+func (z *PasswordService) AddContext(name string, val ValidationContext, opts ...ValidatorOption) {
 
 	if z.vl == nil {
 		z.vl = make(map[string]*validFunc)
@@ -143,7 +229,23 @@ func (z *PasswordService) Add(name string, val Validation) {
 	if v == nil {
 		v = new(validFunc)
 		z.vl[name] = v
+		z.order = append(z.order, name)
 	}
 
-	v.addFunc(name, val)
+	v.addFunc(name, val, opts...)
+}
+
+// remove unregisters a validator.
+func (z *PasswordService) remove(name string) {
+	if _, ok := z.vl[name]; !ok {
+		return
+	}
+
+	delete(z.vl, name)
+	for i, n := range z.order {
+		if n == name {
+			z.order = append(z.order[:i], z.order[i+1:]...)
+			break
+		}
+	}
 }
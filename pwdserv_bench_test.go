@@ -0,0 +1,80 @@
+package pwdserv_test
+
+import (
+	"testing"
+
+	"github.com/DigiRazor/pwdserv"
+)
+
+var benchConfig = []byte(`{
+	"CheckConfirm": true,
+	"CheckMinLength": true,
+	"MinLength": 8,
+	"CheckUserID": true,
+	"CheckUppercase": true,
+	"CheckLowercase": true,
+	"CheckNumeric": true,
+	"CheckSpecialChar": true,
+	"SpecialChar": "!@#$%*+/?",
+	"CheckWhiteSpace": true,
+	"CheckHistory": true,
+	"MinHistory": 3,
+	"CheckBlackList": true
+}`)
+
+func benchService(b *testing.B) *pwdserv.PasswordService {
+	serv := pwdserv.New()
+	if err := serv.SetConfig(benchConfig, []string{"test", "password"}); err != nil {
+		b.Fatal(err)
+	}
+	return serv
+}
+
+func BenchmarkValidate(b *testing.B) {
+	serv := benchService(b)
+	pwd := pwdserv.Password{
+		UserID:          "ABHW089",
+		OldPassword:     "B1ge@rs*",
+		NewPassword:     "yVHn6?R@",
+		ConfirmPassword: "yVHn6?R@",
+		PasswordHistory: []string{"$sG96r#X", "3g9m&9W7"},
+		NewPasswordHash: "yVHn6?R@",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := serv.Validate(&pwd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateInvalid(b *testing.B) {
+	serv := benchService(b)
+	pwd := pwdserv.Password{
+		UserID:          "ABHW089",
+		NewPassword:     "yVHn63R2",
+		ConfirmPassword: "yVHn63R2",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := serv.Validate(&pwd); err == nil {
+			b.Fatal("expected an error")
+		}
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	serv := benchService(b)
+	pwd := pwdserv.Password{
+		UserID:          "ABHW089",
+		NewPassword:     "yVHn6?R@",
+		ConfirmPassword: "yVHn6?R@",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		serv.Evaluate(&pwd)
+	}
+}
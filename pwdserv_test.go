@@ -356,4 +356,91 @@ var _ = Describe("Pwdserv", func() {
 
 	})
 
+	Context("given you have a configured service with non-ASCII special characters", func() {
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{
+			"CheckSpecialChar": true,
+			"SpecialChar": "§€",
+			"CheckUserID": true
+		}`), nil)
+
+		It("should accept a password with one of the special characters.", func() {
+			err := serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6€R2"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should return error for a password without the special characters.", func() {
+			err := serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@"})
+			Expect(err).To(BeEquivalentTo(errors.New("Password must contain at least 1 of the following characters: '§€'.")))
+		})
+	})
+
+	Context("given you have a configured service with a normalization policy", func() {
+		newService := func(normalization string) *pwdserv.PasswordService {
+			cfgData := []byte(`{
+				"CheckConfirm": true,
+				"CheckMinLength": true,
+				"MinLength": 8,
+				"CheckHistory": true,
+				"MinHistory": 3,
+				"Normalization": "` + normalization + `"
+			}`)
+			serv := pwdserv.New()
+			err := serv.SetConfig(cfgData, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			return serv
+		}
+
+		It("should ignore leading and trailing white space with the trim policy.", func() {
+			pwd := pwdserv.Password{
+				OldPassword:     "B1ge@rs*",
+				NewPassword:     " yVHn6?R@",
+				ConfirmPassword: "yVHn6?R@ ",
+			}
+
+			err := newService("trim").Validate(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+
+			pwd.NewPassword = " B1ge@rs* "
+			pwd.ConfirmPassword = "B1ge@rs*"
+			err = newService("").Validate(&pwd)
+			Expect(err).To(BeEquivalentTo(errors.New("You are also not allowed to use any of your previous 3 passwords.")))
+		})
+
+		It("should compare the passwords as typed with the none policy.", func() {
+			pwd := pwdserv.Password{
+				OldPassword:     "B1ge@rs*",
+				NewPassword:     " yVHn6?R@",
+				ConfirmPassword: "yVHn6?R@",
+			}
+
+			err := newService("none").Validate(&pwd)
+			Expect(err).To(BeEquivalentTo(errors.New("Confirmation password does not match.")))
+
+			pwd.NewPassword = " B1ge@rs*"
+			pwd.ConfirmPassword = " B1ge@rs*"
+			err = newService("none").Validate(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should count white space towards the length with the none policy.", func() {
+			pwd := pwdserv.Password{
+				NewPassword:     " yVHn6?R",
+				ConfirmPassword: " yVHn6?R",
+			}
+
+			err := newService("none").Validate(&pwd)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = newService("trim").Validate(&pwd)
+			Expect(err).To(BeEquivalentTo(errors.New("Passwords must be a minimum of 8 characters.")))
+		})
+
+		It("should return an error for an unknown policy when calling SetConfig().", func() {
+			serv := pwdserv.New()
+			err := serv.SetConfig([]byte(`{"Normalization": "upper"}`), nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,189 @@
+package pwdserv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited can be used with errors.Is to detect a *RateLimitError.
+var ErrRateLimited = &RateLimitError{}
+
+// RateLimitError is returned by Validate when the user or application made too many attempts.
+type RateLimitError struct {
+	// Scope is either "UserID" or "ApplicationID".
+	Scope string
+	// RetryAfter is how long to wait before the next attempt.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Too many attempts, retry after %s.", e.RetryAfter)
+}
+
+// Is reports whether target is a *RateLimitError.
+func (e *RateLimitError) Is(target error) bool {
+	_, ok := target.(*RateLimitError)
+	return ok
+}
+
+// RateLimiter decides whether an attempt for a key may go ahead.
+// Shared backends implement it to enforce limits across instances of the service.
+type RateLimiter interface {
+	// Allow takes a token for key. If there is none it returns false and how long to wait.
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// RateRefunder is implemented by a RateLimiter that can give back a token taken by
+// Allow. The token of the UserID is given back when the ApplicationID is throttled,
+// so a busy application does not use up the attempts of its users.
+type RateRefunder interface {
+	Refund(ctx context.Context, key string) error
+}
+
+// SetRateLimit limits the Validate calls per UserID and per ApplicationID,
+// so the service can not be used as an oracle. Either limiter may be nil.
+//
+// Calls without a UserID or ApplicationID share one bucket of the limiter,
+// so leaving them out does not get around the limits.
+func (z *PasswordService) SetRateLimit(byUser, byApplication RateLimiter) {
+	z.byUser = byUser
+	z.byApp = byApplication
+}
+
+func (z *PasswordService) checkLimits(ctx context.Context, model *Password) error {
+	if err := limit(ctx, z.byUser, "UserID", model.UserID); err != nil {
+		return err
+	}
+
+	err := limit(ctx, z.byApp, "ApplicationID", model.ApplicationID)
+	if err != nil && z.byUser != nil {
+		if refunder, ok := z.byUser.(RateRefunder); ok {
+			refunder.Refund(ctx, "UserID:"+model.UserID)
+		}
+	}
+
+	return err
+}
+
+func limit(ctx context.Context, limiter RateLimiter, scope, key string) error {
+	if limiter == nil {
+		return nil
+	}
+
+	ok, retryAfter, err := limiter.Allow(ctx, scope+":"+key)
+	if err != nil {
+		return err
+	}
+
+	if ok == false {
+		return &RateLimitError{Scope: scope, RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// MemoryLimiter is an in-memory token bucket RateLimiter.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	clock   func() time.Time
+	calls   int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryLimiter creates a limiter that allows burst attempts at once, refilled at rate attempts per second.
+func NewMemoryLimiter(rate float64, burst int) (*MemoryLimiter, error) {
+	if !(rate > 0) {
+		return nil, errors.New("Rate must be greater than 0.")
+	}
+	if burst < 1 {
+		return nil, errors.New("Burst must be at least 1.")
+	}
+
+	return &MemoryLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}, nil
+}
+
+// SetClock replaces the clock of the limiter, mostly useful for testing.
+func (l *MemoryLimiter) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = now
+}
+
+// Allow takes a token from the bucket of key.
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	l.calls++
+	if l.calls%1024 == 0 {
+		l.sweep(now)
+	}
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration(math.Ceil((1 - b.tokens) / l.rate * float64(time.Second)))
+		return false, wait, nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+// Refund gives back a token taken from the bucket of key.
+func (l *MemoryLimiter) Refund(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b := l.buckets[key]; b != nil {
+		now := l.now()
+		b.tokens = math.Min(l.burst, l.refill(b, now)+1)
+		b.last = now
+	}
+
+	return nil
+}
+
+func (l *MemoryLimiter) now() time.Time {
+	if l.clock == nil {
+		return time.Now()
+	}
+	return l.clock()
+}
+
+func (l *MemoryLimiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+}
+
+// sweep drops the full buckets, they are the same as new ones.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package pwdserv_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate limiting", func() {
+	Context("given you have a service with rate limits", func() {
+		now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+		clock := func() time.Time { return now }
+
+		It("should throttle a user after the burst when calling Validate().", func() {
+			byUser, err := pwdserv.NewMemoryLimiter(0.5, 2)
+			Expect(err).ToNot(HaveOccurred())
+			byUser.SetClock(clock)
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{}`), nil)
+			serv.SetRateLimit(byUser, nil)
+
+			pwd := pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@"}
+			Expect(serv.Validate(&pwd)).To(Succeed())
+			Expect(serv.Validate(&pwd)).To(Succeed())
+
+			err = serv.Validate(&pwd)
+			Expect(errors.Is(err, pwdserv.ErrRateLimited)).To(BeTrue())
+
+			var limitErr *pwdserv.RateLimitError
+			Expect(errors.As(err, &limitErr)).To(BeTrue())
+			Expect(limitErr.Scope).To(Equal("UserID"))
+			Expect(limitErr.RetryAfter).To(Equal(2 * time.Second))
+
+			other := pwdserv.Password{UserID: "XYZ001", NewPassword: "yVHn6?R@"}
+			Expect(serv.Validate(&other)).To(Succeed())
+		})
+
+		It("should throttle an application across users when calling Validate().", func() {
+			byApp, err := pwdserv.NewMemoryLimiter(1, 1)
+			Expect(err).ToNot(HaveOccurred())
+			byApp.SetClock(clock)
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{}`), nil)
+			serv.SetRateLimit(nil, byApp)
+
+			Expect(serv.Validate(&pwdserv.Password{UserID: "A", ApplicationID: "APP1"})).To(Succeed())
+
+			err = serv.Validate(&pwdserv.Password{UserID: "B", ApplicationID: "APP1"})
+			Expect(err).To(MatchError("Too many attempts, retry after 1s."))
+		})
+
+		It("should throttle the calls without a UserID in a shared bucket.", func() {
+			byUser, err := pwdserv.NewMemoryLimiter(1, 1)
+			Expect(err).ToNot(HaveOccurred())
+			byUser.SetClock(clock)
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{}`), nil)
+			serv.SetRateLimit(byUser, nil)
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(Succeed())
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "3g9m&9W7"})
+			Expect(errors.Is(err, pwdserv.ErrRateLimited)).To(BeTrue())
+		})
+
+		It("should not take the token of the user when the application is throttled.", func() {
+			byUser, err := pwdserv.NewMemoryLimiter(1, 1)
+			Expect(err).ToNot(HaveOccurred())
+			byUser.SetClock(clock)
+			byApp, err := pwdserv.NewMemoryLimiter(1, 1)
+			Expect(err).ToNot(HaveOccurred())
+			byApp.SetClock(clock)
+
+			serv := pwdserv.New()
+			var _ = serv.SetConfig([]byte(`{}`), nil)
+			serv.SetRateLimit(byUser, byApp)
+
+			Expect(serv.Validate(&pwdserv.Password{UserID: "A", ApplicationID: "APP1"})).To(Succeed())
+
+			err = serv.Validate(&pwdserv.Password{UserID: "B", ApplicationID: "APP1"})
+			Expect(errors.Is(err, pwdserv.ErrRateLimited)).To(BeTrue())
+
+			Expect(serv.Validate(&pwdserv.Password{UserID: "B", ApplicationID: "APP2"})).To(Succeed())
+		})
+	})
+
+	It("should refill the bucket over time.", func() {
+		now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+		limiter, err := pwdserv.NewMemoryLimiter(1, 1)
+		Expect(err).ToNot(HaveOccurred())
+		limiter.SetClock(func() time.Time { return now })
+
+		ok, _, _ := limiter.Allow(context.Background(), "key")
+		Expect(ok).To(BeTrue())
+
+		ok, wait, _ := limiter.Allow(context.Background(), "key")
+		Expect(ok).To(BeFalse())
+		Expect(wait).To(Equal(time.Second))
+
+		now = now.Add(time.Second)
+		ok, _, _ = limiter.Allow(context.Background(), "key")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should refuse a rate or burst that can not refill.", func() {
+		_, err := pwdserv.NewMemoryLimiter(0, 1)
+		Expect(err).To(MatchError("Rate must be greater than 0."))
+
+		_, err = pwdserv.NewMemoryLimiter(1, 0)
+		Expect(err).To(MatchError("Burst must be at least 1."))
+	})
+})
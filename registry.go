@@ -0,0 +1,135 @@
+package pwdserv
+
+import (
+	"fmt"
+)
+
+// Names of the build-in validators, registered by SetConfig.
+// They are also the codes of their requirements and metrics.
+const (
+	ValidatorConfirm           = "CCP"
+	ValidatorLength            = "CL"
+	ValidatorUserID            = "CUN"
+	ValidatorUppercase         = "CUC"
+	ValidatorLowercase         = "CLC"
+	ValidatorNumeric           = "CNC"
+	ValidatorSpecialChar       = "CSC"
+	ValidatorWhiteSpace        = "CWS"
+	ValidatorHistory           = "CH"
+	ValidatorBlackList         = "CBL"
+	ValidatorPasswordAge       = "CPA"
+	ValidatorContextWords      = "CCW"
+	ValidatorHistorySimilarity = "CHS"
+)
+
+// ValidatorInfo describes a registered validator, see List.
+type ValidatorInfo struct {
+	// Name the validator is registered under.
+	Name string
+	// Description is the requirement text in the DefaultLocale, empty when
+	// the validator has no Description or is switched off in the configuration.
+	Description string
+	// Enabled is false when the validator is switched off with Disable.
+	Enabled bool
+}
+
+// addBuildIns registers the build-in validators, once, so validators that were
+// removed or replaced stay that way when the configuration is reloaded.
+func (z *PasswordService) addBuildIns() {
+	if z.buildIns {
+		return
+	}
+	z.buildIns = true
+
+	z.Add(ValidatorConfirm, ComfirmPassword, Cheap(), Description(describeConfirm))
+	z.Add(ValidatorLength, CheckLength, Cheap(), Description(describeLength))
+	z.Add(ValidatorUserID, CheckUserID, Cheap(), Description(describeUserID))
+	z.AddContext(ValidatorUppercase, byClasses(checkUppercase), Cheap(), Description(describeUppercase))
+	z.AddContext(ValidatorLowercase, byClasses(checkLowercase), Cheap(), Description(describeLowercase))
+	z.AddContext(ValidatorNumeric, byClasses(checkNumeric), Cheap(), Description(describeNumeric))
+	z.AddContext(ValidatorSpecialChar, byClasses(checkSpecialChar), Cheap(), Description(describeSpecialChar))
+	z.AddContext(ValidatorWhiteSpace, byClasses(checkWhiteSpace), Cheap(), Description(describeWhiteSpace))
+	z.Add(ValidatorHistory, CheckHistory, Cheap(), Description(describeHistory))
+	z.AddContext(ValidatorBlackList, checkBlackList, Cheap(), blackListed(), Description(describeBlackList))
+	z.Add(ValidatorPasswordAge, CheckPasswordAge, Cheap(), Description(describePasswordAge))
+	z.Add(ValidatorContextWords, CheckContextWords, Cheap(), Description(describeContextWords))
+	z.Add(ValidatorHistorySimilarity, CheckHistorySimilarity, Description(describeHistorySimilarity))
+}
+
+// Has reports whether a validator is registered under name.
+func (z *PasswordService) Has(name string) bool {
+	_, ok := z.vl[name]
+	return ok
+}
+
+// Remove unregisters the validator, build-in or custom.
+func (z *PasswordService) Remove(name string) error {
+	if !z.Has(name) {
+		return fmt.Errorf("Validator '%s' is not registered.", name)
+	}
+
+	z.remove(name)
+
+	return nil
+}
+
+// Replace swaps the validation of a registered validator, which keeps its
+// position and enabled state. The options apply as with Add, so a replaced
+// build-in needs a Description to stay in Describe.
+func (z *PasswordService) Replace(name string, val Validation, opts ...ValidatorOption) error {
+	return z.ReplaceContext(name, AdaptValidation(val), opts...)
+}
+
+// ReplaceContext is the same as Replace, for a context-aware validator.
+func (z *PasswordService) ReplaceContext(name string, val ValidationContext, opts ...ValidatorOption) error {
+	if !z.Has(name) {
+		return fmt.Errorf("Validator '%s' is not registered.", name)
+	}
+
+	z.AddContext(name, val, opts...)
+
+	return nil
+}
+
+// Enable switches a validator that was disabled back on.
+func (z *PasswordService) Enable(name string) error {
+	return z.setEnabled(name, true)
+}
+
+// Disable switches a validator off without removing it. Disabled validators
+// are skipped by Validate, Evaluate and Describe.
+func (z *PasswordService) Disable(name string) error {
+	return z.setEnabled(name, false)
+}
+
+func (z *PasswordService) setEnabled(name string, enabled bool) error {
+	value, ok := z.vl[name]
+	if !ok {
+		return fmt.Errorf("Validator '%s' is not registered.", name)
+	}
+
+	value.disabled = !enabled
+
+	return nil
+}
+
+// List returns the registered validators in the order they run.
+func (z *PasswordService) List() []ValidatorInfo {
+	config := z.config
+	if config == nil {
+		config = &PasswordRules{}
+	}
+
+	infos := make([]ValidatorInfo, 0, len(z.order))
+	for _, name := range z.order {
+		value := z.vl[name]
+
+		info := ValidatorInfo{Name: name, Enabled: !value.disabled}
+		if req := z.requirement(value, DefaultLocale, config); req != nil {
+			info.Description = req.Text
+		}
+		infos = append(infos, info)
+	}
+
+	return infos
+}
@@ -0,0 +1,68 @@
+package pwdserv_test
+
+import (
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var serv *pwdserv.PasswordService
+	var cfg = []byte(`{"CheckMinLength": true, "MinLength": 8, "CheckNumeric": true}`)
+
+	BeforeEach(func() {
+		serv = pwdserv.New()
+		Expect(serv.SetConfig(cfg, nil)).To(Succeed())
+	})
+
+	Context("given you have a service with the build-in validators", func() {
+		It("should list the validators in the order they run when calling List().", func() {
+			list := serv.List()
+			Expect(list).To(HaveLen(13))
+			Expect(list[0]).To(Equal(pwdserv.ValidatorInfo{Name: pwdserv.ValidatorConfirm, Enabled: true}))
+			Expect(list[1]).To(Equal(pwdserv.ValidatorInfo{Name: pwdserv.ValidatorLength, Description: "At least 8 characters.", Enabled: true}))
+		})
+
+		It("should report the registered validators when calling Has().", func() {
+			Expect(serv.Has(pwdserv.ValidatorBlackList)).To(BeTrue())
+			Expect(serv.Has("Unknown")).To(BeFalse())
+		})
+
+		It("should skip a disabled validator until it is enabled.", func() {
+			Expect(serv.Disable(pwdserv.ValidatorLength)).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6"})).To(Succeed())
+
+			reqs, _ := serv.Describe("en")
+			Expect(reqs).To(HaveLen(1))
+			Expect(serv.List()[1].Enabled).To(BeFalse())
+
+			Expect(serv.Enable(pwdserv.ValidatorLength)).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6"})).ToNot(Succeed())
+		})
+
+		It("should keep removed, replaced and disabled validators when calling SetConfig() again.", func() {
+			Expect(serv.Remove(pwdserv.ValidatorNumeric)).To(Succeed())
+			Expect(serv.Replace(pwdserv.ValidatorLength, func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				return false, errors.New("Replaced.")
+			})).To(Succeed())
+			Expect(serv.Disable(pwdserv.ValidatorConfirm)).To(Succeed())
+
+			Expect(serv.SetConfig(cfg, nil)).To(Succeed())
+
+			Expect(serv.Has(pwdserv.ValidatorNumeric)).To(BeFalse())
+			Expect(serv.List()[0].Enabled).To(BeFalse())
+			Expect(serv.List()[1].Name).To(Equal(pwdserv.ValidatorLength))
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(BeEquivalentTo(errors.New("Replaced.")))
+		})
+
+		It("should return an error for a validator that is not registered.", func() {
+			Expect(serv.Remove("Unknown")).To(MatchError("Validator 'Unknown' is not registered."))
+			Expect(serv.Replace("Unknown", pwdserv.CheckLength)).To(MatchError("Validator 'Unknown' is not registered."))
+			Expect(serv.Disable("Unknown")).To(MatchError("Validator 'Unknown' is not registered."))
+			Expect(serv.Enable("Unknown")).To(MatchError("Validator 'Unknown' is not registered."))
+		})
+	})
+})
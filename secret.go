@@ -0,0 +1,124 @@
+package pwdserv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Secret holds a plaintext password in a buffer that can be wiped.
+// It is redacted when printed with any verb or marshalled to JSON.
+type Secret []byte
+
+// Wipe zeroes the buffer.
+func (s Secret) Wipe() {
+	clear(s)
+}
+
+// String returns "[REDACTED]".
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString returns the redacted Go syntax of the secret.
+func (s Secret) GoString() string {
+	return `pwdserv.Secret("` + redacted + `")`
+}
+
+// Format writes "[REDACTED]" for every verb, so %x or %d can not leak the bytes either.
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		f.Write([]byte(s.GoString()))
+		return
+	}
+	f.Write([]byte(redacted))
+}
+
+// MarshalJSON returns the redacted string.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// SecretPassword is the variant of Password that keeps the plaintext passwords
+// in Secret buffers, see ValidateSecret.
+type SecretPassword struct {
+	// JWTToken can be used to store the token for the current session.
+	JWTToken string
+	// ApplicationID can be used to store a token for the current application.
+	ApplicationID string
+	// UserID is used to with the CheckUserID config switch.
+	UserID string
+	// OldPassword the current password for the user.
+	OldPassword Secret
+	// NewPassword the password to be validated.
+	NewPassword Secret
+	// ConfirmPassword is a confirmation of the new password.
+	ConfirmPassword Secret
+
+	// PasswordHistory is a slice containing the history of passwords
+	// previously used by the user.
+	PasswordHistory []string
+
+	// NewPasswordHash is used to compare with PasswordHistory.
+	NewPasswordHash string
+
+	// PasswordChanged is the time the current password was set.
+	PasswordChanged time.Time
+
+	// History is the timestamped alternative to PasswordHistory.
+	History []HistoryEntry
+
+	// UserContext holds details of the user.
+	UserContext UserContext
+
+	// WipeAfterValidate zeroes the Secret buffers when ValidateSecret returns.
+	WipeAfterValidate bool
+}
+
+// Wipe zeroes the OldPassword, NewPassword and ConfirmPassword buffers.
+func (p *SecretPassword) Wipe() {
+	p.OldPassword.Wipe()
+	p.NewPassword.Wipe()
+	p.ConfirmPassword.Wipe()
+}
+
+// ValidateSecret is the same as ValidateContext for a SecretPassword.
+//
+// The validators see string copies of the Secret buffers. Go strings can not be
+// changed once made, so the copies are left to the garbage collector and only the
+// buffers of the caller are zeroed afterwards, when WipeAfterValidate is set.
+func (z *PasswordService) ValidateSecret(ctx context.Context, model *SecretPassword) error {
+	if model.WipeAfterValidate {
+		defer model.Wipe()
+	}
+
+	pwd := Password{
+		JWTToken:        model.JWTToken,
+		ApplicationID:   model.ApplicationID,
+		UserID:          model.UserID,
+		OldPassword:     string(model.OldPassword),
+		NewPassword:     string(model.NewPassword),
+		ConfirmPassword: string(model.ConfirmPassword),
+		PasswordHistory: model.PasswordHistory,
+		NewPasswordHash: model.NewPasswordHash,
+		PasswordChanged: model.PasswordChanged,
+		History:         model.History,
+		UserContext:     model.UserContext,
+	}
+
+	return z.ValidateContext(ctx, &pwd)
+}
+
+// String returns the password with every password, hash and token field redacted,
+// so printing it with %v or %+v does not leak secrets into logs.
+func (p Password) String() string {
+	type plain Password
+	return fmt.Sprintf("%+v", plain(p.Redacted()))
+}
+
+// GoString is the redacted Go syntax of the password for %#v.
+func (p Password) GoString() string {
+	type plain Password
+	return "pwdserv.Password" + strings.TrimPrefix(fmt.Sprintf("%#v", plain(p.Redacted())), "pwdserv.plain")
+}
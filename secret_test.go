@@ -0,0 +1,92 @@
+package pwdserv_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret", func() {
+	It("should never print or marshal its content.", func() {
+		secret := pwdserv.Secret("yVHn6?R@")
+
+		Expect(fmt.Sprintf("%s %v %+v %x %d", secret, secret, secret, secret, secret)).ToNot(ContainSubstring("yVHn6"))
+		Expect(fmt.Sprintf("%#v", secret)).To(Equal(`pwdserv.Secret("[REDACTED]")`))
+
+		data, err := json.Marshal(struct{ Password pwdserv.Secret }{secret})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`{"Password":"[REDACTED]"}`))
+	})
+
+	It("should zero the buffer when calling Wipe().", func() {
+		secret := pwdserv.Secret("yVHn6?R@")
+		secret.Wipe()
+
+		Expect([]byte(secret)).To(Equal(make([]byte, 8)))
+	})
+
+	It("should redact a Password printed with %+v and %#v.", func() {
+		pwd := pwdserv.Password{UserID: "ABHW089", NewPassword: "yVHn6?R@", ConfirmPassword: "yVHn6?R@"}
+
+		Expect(fmt.Sprintf("%+v", pwd)).To(ContainSubstring("UserID:ABHW089"))
+		Expect(fmt.Sprintf("%+v", pwd)).ToNot(ContainSubstring("yVHn6"))
+		Expect(fmt.Sprintf("%v", &pwd)).ToNot(ContainSubstring("yVHn6"))
+		Expect(fmt.Sprintf("%#v", pwd)).To(HavePrefix("pwdserv.Password{"))
+		Expect(fmt.Sprintf("%#v", pwd)).ToNot(ContainSubstring("yVHn6"))
+	})
+
+	Context("given you have a configured service", func() {
+		serv := pwdserv.New()
+		var _ = serv.SetConfig([]byte(`{
+			"CheckConfirm": true,
+			"CheckMinLength": true,
+			"MinLength": 8
+		}`), nil)
+
+		It("should validate and wipe a SecretPassword when calling ValidateSecret().", func() {
+			pwd := pwdserv.SecretPassword{
+				UserID:            "ABHW089",
+				NewPassword:       pwdserv.Secret("yVHn6?R@"),
+				ConfirmPassword:   pwdserv.Secret("yVHn6?Ra"),
+				WipeAfterValidate: true,
+			}
+
+			err := serv.ValidateSecret(context.Background(), &pwd)
+			Expect(err).To(BeEquivalentTo(errors.New("Confirmation password does not match.")))
+			Expect([]byte(pwd.NewPassword)).To(Equal(make([]byte, 8)))
+			Expect([]byte(pwd.ConfirmPassword)).To(Equal(make([]byte, 8)))
+		})
+
+		It("should not change the passwords seen by the validators when wiping.", func() {
+			var seen string
+
+			serv := pwdserv.New()
+			serv.Add("Keep", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				seen = password.NewPassword
+				return true, nil
+			})
+
+			pwd := pwdserv.SecretPassword{NewPassword: pwdserv.Secret("yVHn6?R@"), WipeAfterValidate: true}
+			Expect(serv.ValidateSecret(context.Background(), &pwd)).To(Succeed())
+			Expect([]byte(pwd.NewPassword)).To(Equal(make([]byte, 8)))
+			Expect(seen).To(Equal("yVHn6?R@"))
+		})
+
+		It("should keep the buffers when the caller did not opt in.", func() {
+			pwd := pwdserv.SecretPassword{
+				NewPassword:     pwdserv.Secret("yVHn6?R@"),
+				ConfirmPassword: pwdserv.Secret("yVHn6?R@"),
+			}
+
+			err := serv.ValidateSecret(context.Background(), &pwd)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(pwd.NewPassword)).To(Equal("yVHn6?R@"))
+		})
+	})
+})
@@ -0,0 +1,183 @@
+package pwdserv
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Severity is how a failed validation affects the password change.
+type Severity int
+
+const (
+	// SeverityError blocks the password change. It is the default.
+	SeverityError Severity = iota
+	// SeverityWarning is reported in the Result, without blocking the password change.
+	SeverityWarning
+	// SeverityInfo is reported in the Result as advice only.
+	SeverityInfo
+)
+
+var severityNames = []string{"error", "warning", "info"}
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+
+	return severityNames[s]
+}
+
+// MarshalText returns the name of the severity, for JSON configurations.
+func (s Severity) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(severityNames) {
+		return nil, fmt.Errorf("Unknown Severity %d.", int(s))
+	}
+
+	return []byte(severityNames[s]), nil
+}
+
+// UnmarshalText parses "error", "warning" or "info".
+func (s *Severity) UnmarshalText(text []byte) error {
+	for i, name := range severityNames {
+		if string(text) == name {
+			*s = Severity(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown Severity '%s'.", text)
+}
+
+// WithSeverity sets the severity of a validator's failures.
+// The Severities of the configuration take precedence.
+func WithSeverity(s Severity) ValidatorOption {
+	return func(n *validFunc) {
+		n.severity = s
+	}
+}
+
+// compileSeverities checks that the Severities of cfg name a registered validator,
+// or a custom or expression rule of cfg, so a misspelled name is not enforced silently.
+func (z *PasswordService) compileSeverities(cfg *PasswordRules) error {
+	for _, name := range slices.Sorted(maps.Keys(cfg.Severities)) {
+		if !z.isValidator(name) && cfg.customRule(name) == nil && cfg.exprRule(name) == nil {
+			return fmt.Errorf("Unknown validator '%s' in the Severities.", name)
+		}
+	}
+
+	return nil
+}
+
+// severity returns the severity of the validator in the active configuration.
+func (z *PasswordService) severity(value *validFunc) Severity {
+	return severityIn(z.config, value)
+}
+
+// severityIn returns the severity of the validator in config: the Severities,
+// then the configured rule's Severity, then the validator's own.
+func severityIn(config *PasswordRules, value *validFunc) Severity {
+	if config == nil {
+		return value.severity
+	}
+
+	if s, ok := config.Severities[value.name]; ok {
+		return s
+	}
+	if rule := config.customRule(value.name); rule != nil {
+		return rule.Severity
+	}
+	if rule := config.exprRule(value.name); rule != nil {
+		return rule.Severity
+	}
+
+	return value.severity
+}
+
+// Finding is a failed validation in a Result.
+type Finding struct {
+	// Code is the name of the validator.
+	Code string
+	// Severity of the validator.
+	Severity Severity
+	// Message describes the failure.
+	Message string
+	// Err is the error returned by the validator.
+	Err error `json:"-"`
+}
+
+// Result is the outcome of ValidateResult.
+type Result struct {
+	// Err is the error Validate returns: the blocking failures, or the error
+	// that stopped the validation. It is nil when the password is accepted.
+	Err error `json:"-"`
+	// Failures are the failed validations with SeverityError.
+	Failures []Finding `json:",omitempty"`
+	// Warnings are the failed validations with SeverityWarning or SeverityInfo,
+	// which do not block the password change.
+	Warnings []Finding `json:",omitempty"`
+}
+
+// Valid reports whether the password is accepted, whatever the warnings.
+func (r *Result) Valid() bool {
+	return r.Err == nil
+}
+
+// ValidateResult is the same as ValidateContext, but separates the blocking
+// failures from the warnings instead of returning only an error.
+func (z *PasswordService) ValidateResult(ctx context.Context, model *Password) *Result {
+	failures, err := z.validate(ctx, model)
+
+	res := &Result{Err: err}
+	for _, f := range failures {
+		finding := Finding{Code: f.name, Severity: f.severity, Message: f.err.Error(), Err: f.err}
+		if f.blocking() {
+			res.Failures = append(res.Failures, finding)
+		} else {
+			res.Warnings = append(res.Warnings, finding)
+		}
+	}
+
+	return res
+}
+
+// validate runs the validators and reports the outcome to the metrics and audit sink.
+// It returns every failure, blocking or not, and the error for Validate.
+func (z *PasswordService) validate(ctx context.Context, model *Password) ([]failure, error) {
+	if len(z.vl) == 0 {
+		return nil, errNoValidators
+	}
+
+	// the build-ins need the configuration, which is not loaded when SetConfig failed
+	if z.buildIns && z.config == nil {
+		return nil, errNoConfig
+	}
+
+	var failures []failure
+
+	start := time.Now()
+	err := z.checkLimits(ctx, model)
+	if err == nil {
+		failures, err = z.run(ctx, model)
+	}
+	result := z.errorOf(failures, err)
+
+	if z.candidate != nil && z.shadow != nil && err == nil {
+		z.queueShadow(ctx, model, failures)
+	}
+
+	if z.metrics != nil {
+		z.metrics.ObserveValidate(outcomeOf(result == nil, result), time.Since(start))
+	}
+
+	if z.audit != nil {
+		if err := z.emitAudit(model, failures, err); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return failures, result
+}
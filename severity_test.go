@@ -0,0 +1,101 @@
+package pwdserv_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Severity", func() {
+	Context("given you have a rule switched to a warning in the configuration", func() {
+		var serv *pwdserv.PasswordService
+
+		BeforeEach(func() {
+			serv = pwdserv.New()
+			err := serv.SetConfig([]byte(`{
+				"CheckMinLength": true,
+				"MinLength": 12,
+				"CheckNumeric": true,
+				"Severities": {"CL": "warning"}
+			}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should not fail the password when calling Validate().", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@"})).To(Succeed())
+		})
+
+		It("should report the warning when calling ValidateResult().", func() {
+			res := serv.ValidateResult(context.Background(), &pwdserv.Password{NewPassword: "yVHn6?R@"})
+			Expect(res.Valid()).To(BeTrue())
+			Expect(res.Failures).To(BeEmpty())
+			Expect(res.Warnings).To(HaveLen(1))
+			Expect(res.Warnings[0].Code).To(Equal(pwdserv.ValidatorLength))
+			Expect(res.Warnings[0].Severity).To(Equal(pwdserv.SeverityWarning))
+			Expect(res.Warnings[0].Message).To(Equal("Passwords must be a minimum of 12 characters."))
+		})
+
+		It("should keep validating after the warning in fail-fast mode.", func() {
+			res := serv.ValidateResult(context.Background(), &pwdserv.Password{NewPassword: "yVHn?R@"})
+			Expect(res.Valid()).To(BeFalse())
+			Expect(res.Err).To(BeEquivalentTo(errors.New("Password must contain at least 1 numeric character.")))
+			Expect(res.Warnings).To(HaveLen(1))
+			Expect(res.Failures).To(HaveLen(1))
+			Expect(res.Failures[0].Code).To(Equal(pwdserv.ValidatorNumeric))
+		})
+
+		It("should not fail the evaluation.", func() {
+			eval := serv.Evaluate(&pwdserv.Password{NewPassword: "yVHn6?R@"})
+			Expect(eval.Valid).To(BeTrue())
+			Expect(eval.Rules[0].Passed).To(BeFalse())
+			Expect(eval.Rules[0].Severity).To(Equal(pwdserv.SeverityWarning))
+		})
+	})
+
+	Context("given you have validators and rules with a severity", func() {
+		It("should only block on errors.", func() {
+			serv := pwdserv.New()
+			err := serv.SetConfig([]byte(`{"CustomRules": [
+				{"Name": "NoSeason", "Pattern": "(?i)^summer", "Message": "Seasons are weak.", "Severity": "info"}
+			]}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			serv.Add("Weak", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				return false, errors.New("Password is weak.")
+			}, pwdserv.WithSeverity(pwdserv.SeverityWarning))
+
+			res := serv.ValidateResult(context.Background(), &pwdserv.Password{NewPassword: "Summer2017"})
+			Expect(res.Valid()).To(BeTrue())
+			Expect(res.Warnings).To(HaveLen(2))
+			Expect(res.Warnings[0].Severity).To(Equal(pwdserv.SeverityInfo))
+			Expect(res.Warnings[1].Message).To(Equal("Password is weak."))
+		})
+
+		It("should return an error for an unknown severity when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"Severities": {"CL": "fatal"}}`), nil)
+			Expect(err).To(MatchError("Unknown Severity 'fatal'."))
+		})
+
+		It("should return an error for an unknown validator when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"Severities": {"Cl": "warning"}}`), nil)
+			Expect(err).To(MatchError("Unknown validator 'Cl' in the Severities."))
+
+			err = pwdserv.New().SetConfig([]byte(`{
+				"CustomRules": [{"Name": "NoSeason", "Pattern": "(?i)summer", "Message": "No seasons."}],
+				"Severities": {"NoSeason": "warning", "CL": "info"}
+			}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should marshal the severity by name.", func() {
+			data, err := json.Marshal(pwdserv.Finding{Code: "CL", Severity: pwdserv.SeverityWarning, Message: "Short."})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"Code":"CL","Severity":"warning","Message":"Short."}`))
+		})
+	})
+})
@@ -0,0 +1,255 @@
+package pwdserv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ShadowRecord describes a password change attempt on which the candidate
+// configuration disagrees with the active one. The Password is redacted.
+type ShadowRecord struct {
+	// Time of the attempt.
+	Time time.Time
+	// Password is the redacted password that was validated.
+	Password Password
+	// Passed is true if the active configuration accepted the new password.
+	Passed bool
+	// Failed holds the names of the blocking validators that failed with the active
+	// configuration. In fail-fast mode it holds at most one name.
+	Failed []string `json:",omitempty"`
+	// CandidatePassed is true if the candidate configuration would accept the new password.
+	CandidatePassed bool
+	// CandidateFailed holds the names of every blocking validator that fails with the
+	// candidate configuration.
+	CandidateFailed []string `json:",omitempty"`
+}
+
+// ShadowSink receives a ShadowRecord for every disagreement between the
+// active and candidate configuration.
+type ShadowSink interface {
+	Shadow(record *ShadowRecord) error
+}
+
+// SetCandidate parses a candidate configuration (JSON) to evaluate alongside the
+// active one, to measure the impact of a rule change before it is made with SetConfig.
+//
+// Validate only enforces the active configuration, and reports the attempts on which
+// the two disagree to the ShadowSink. The rules of the candidate are registered as
+// validators that pass with the active configuration.
+func (z *PasswordService) SetCandidate(configData []byte, blackList []string) error {
+	var cfg *PasswordRules
+
+	z.addBuildIns()
+
+	err := json.Unmarshal(configData, &cfg)
+	if err != nil {
+		return err
+	}
+
+	cfg.BlackList = blackList
+
+	if err := z.compile(cfg); err != nil {
+		return err
+	}
+
+	z.candidate = cfg
+	z.addRules()
+
+	return nil
+}
+
+// ClearCandidate stops evaluating the candidate configuration.
+func (z *PasswordService) ClearCandidate() {
+	z.candidate = nil
+	z.addRules()
+}
+
+// SetShadowSink sets the sink that disagreements with the candidate configuration
+// are reported to. Errors of the sink are ignored, so the candidate never changes
+// the outcome of Validate.
+//
+// The candidate is evaluated in the background, so it does not add to the latency
+// of Validate. At most shadowQueue attempts are evaluated at a time; attempts
+// beyond that are not shadowed. Use WaitShadow to wait for the evaluations.
+func (z *PasswordService) SetShadowSink(sink ShadowSink) {
+	z.shadow = sink
+	z.shadowSlots = make(chan struct{}, shadowQueue)
+}
+
+// shadowQueue is the number of attempts evaluated with the candidate at a time.
+const shadowQueue = 64
+
+// WaitShadow waits for the evaluations of the candidate configuration in the background.
+func (z *PasswordService) WaitShadow() {
+	z.shadowWG.Wait()
+}
+
+// shadowJob is an attempt to evaluate with the candidate configuration. It holds
+// copies of the password, candidate configuration and validators, so the caller
+// and the service may change them while the job runs.
+type shadowJob struct {
+	ctx        context.Context
+	model      Password
+	candidate  *PasswordRules
+	validators []validFunc
+	sink       ShadowSink
+	record     ShadowRecord
+}
+
+// queueShadow evaluates the candidate configuration in the background, unless the
+// queue is full, and reports a disagreement with the failures of the active one.
+func (z *PasswordService) queueShadow(ctx context.Context, model *Password, failures []failure) {
+	slots := z.shadowSlots
+	select {
+	case slots <- struct{}{}:
+	default:
+		return
+	}
+
+	// the setters, like SetClock, write to the loaded candidate
+	candidate := *z.candidate
+
+	job := &shadowJob{
+		// the values of the context, without the cancellation of the Validate call
+		ctx:       context.WithoutCancel(ctx),
+		model:     *model,
+		candidate: &candidate,
+		sink:      z.shadow,
+		record: ShadowRecord{
+			Time:     z.now(),
+			Password: model.Redacted(),
+			Passed:   !blocking(failures),
+		},
+	}
+	job.model.PasswordHistory = slices.Clone(model.PasswordHistory)
+	job.model.History = slices.Clone(model.History)
+	job.model.UserContext.Words = slices.Clone(model.UserContext.Words)
+
+	for _, name := range z.order {
+		if value := z.vl[name]; !value.disabled {
+			job.validators = append(job.validators, *value)
+		}
+	}
+
+	for _, f := range failures {
+		if f.blocking() {
+			job.record.Failed = append(job.record.Failed, f.name)
+		}
+	}
+
+	z.shadowWG.Add(1)
+	go func() {
+		defer func() {
+			<-slots
+			z.shadowWG.Done()
+		}()
+
+		job.run()
+	}()
+}
+
+// run evaluates the candidate configuration and reports a disagreement to the sink.
+func (job *shadowJob) run() {
+	candidate := job.runCandidate()
+
+	job.record.CandidatePassed = len(candidate) == 0
+	job.record.CandidateFailed = candidate
+	if job.record.Passed == job.record.CandidatePassed {
+		return
+	}
+
+	_ = job.sink.Shadow(&job.record)
+}
+
+// runCandidate runs every enabled validator with the candidate configuration, serially
+// and without middleware or metrics, and returns the names of the blocking failures.
+func (job *shadowJob) runCandidate() []string {
+	var failed []string
+
+	ctx := withClasses(job.ctx, &job.model, job.candidate)
+
+	for i := range job.validators {
+		value := &job.validators[i]
+
+		isvalid, _ := value.validation(ctx, &job.model, job.candidate)
+		if isvalid == false && severityIn(job.candidate, value) == SeverityError {
+			failed = append(failed, value.name)
+		}
+	}
+
+	return failed
+}
+
+// ShadowWriter is a ShadowSink that writes the records as JSON lines,
+// the input of SummarizeShadow and the pwdshadow command.
+type ShadowWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewShadowWriter creates a ShadowWriter that writes to w.
+func NewShadowWriter(w io.Writer) *ShadowWriter {
+	return &ShadowWriter{enc: json.NewEncoder(w)}
+}
+
+// Shadow writes the record as a JSON line.
+func (s *ShadowWriter) Shadow(record *ShadowRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.enc.Encode(record)
+}
+
+// ShadowSummary summarises the disagreements written by a ShadowWriter.
+type ShadowSummary struct {
+	// Records is the number of disagreements.
+	Records int
+	// Rejected is the number of passwords the candidate would reject, but are accepted.
+	Rejected int
+	// Accepted is the number of passwords the candidate would accept, but are rejected.
+	Accepted int
+	// Rules counts by validator name how often it fails the passwords the candidate would reject.
+	Rules map[string]int
+}
+
+// SummarizeShadow reads the JSON lines written by a ShadowWriter.
+func SummarizeShadow(r io.Reader) (*ShadowSummary, error) {
+	sum := &ShadowSummary{Rules: make(map[string]int)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var record ShadowRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("Shadow log line %d: %w", n, err)
+		}
+
+		sum.Records++
+		if record.Passed {
+			sum.Rejected++
+			for _, name := range record.CandidateFailed {
+				sum.Rules[name]++
+			}
+		} else {
+			sum.Accepted++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sum, nil
+}
@@ -0,0 +1,128 @@
+package pwdserv_test
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// blockingSink holds the records until release is closed.
+type blockingSink struct {
+	release chan struct{}
+	sink    pwdserv.ShadowSink
+}
+
+func (s blockingSink) Shadow(record *pwdserv.ShadowRecord) error {
+	<-s.release
+	return s.sink.Shadow(record)
+}
+
+var _ = Describe("Shadow", func() {
+	Context("given you have a candidate configuration with a longer MinLength", func() {
+		var (
+			serv *pwdserv.PasswordService
+			out  *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			serv = pwdserv.New()
+			Expect(serv.SetConfig([]byte(`{"CheckMinLength": true, "MinLength": 8, "CheckNumeric": true}`), nil)).To(Succeed())
+			Expect(serv.SetCandidate([]byte(`{
+				"CheckMinLength": true,
+				"MinLength": 12,
+				"CustomRules": [{"Name": "NoSeason", "Pattern": "(?i)^summer", "Message": "No seasons."}]
+			}`), nil)).To(Succeed())
+
+			out = new(bytes.Buffer)
+			serv.SetShadowSink(pwdserv.NewShadowWriter(out))
+		})
+
+		It("should only enforce the active configuration when calling Validate().", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Summer2017"})).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@xQ2!"})).To(Succeed())
+		})
+
+		It("should report the disagreements to the sink.", func() {
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "Summer2017"})
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@xQ2!"})
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "yVHn?R@xQ!zz"})
+			serv.WaitShadow()
+
+			sum, err := pwdserv.SummarizeShadow(out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sum.Records).To(Equal(2))
+			Expect(sum.Rejected).To(Equal(1))
+			Expect(sum.Accepted).To(Equal(1))
+			Expect(sum.Rules).To(Equal(map[string]int{"CL": 1, "NoSeason": 1}))
+		})
+
+		It("should redact the password in the records.", func() {
+			var _ = serv.Validate(&pwdserv.Password{UserID: "ABHW089", NewPassword: "Summer2017"})
+			serv.WaitShadow()
+			Expect(out.String()).To(ContainSubstring(`"UserID":"ABHW089"`))
+			Expect(out.String()).ToNot(ContainSubstring("Summer2017"))
+		})
+
+		It("should evaluate the candidate in the background.", func() {
+			release := make(chan struct{})
+			serv.SetShadowSink(blockingSink{release, pwdserv.NewShadowWriter(out)})
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				Expect(serv.Validate(&pwdserv.Password{NewPassword: "Summer2017"})).To(Succeed())
+			}()
+			Eventually(done).Should(BeClosed())
+
+			close(release)
+			serv.WaitShadow()
+			Expect(out.String()).To(ContainSubstring(`"CandidateFailed":["CL","NoSeason"]`))
+		})
+
+		It("should evaluate a copy of the candidate while the setters change it.", func() {
+			Expect(serv.SetCandidate([]byte(`{"CheckPasswordAge": true, "MinPasswordAge": 1}`), nil)).To(Succeed())
+
+			release := make(chan struct{})
+			serv.SetShadowSink(blockingSink{release, pwdserv.NewShadowWriter(out)})
+			serv.Add("Hold", func(password *pwdserv.Password, config *pwdserv.PasswordRules) (bool, error) {
+				if config.CheckPasswordAge {
+					<-release
+				}
+				return true, nil
+			})
+
+			changed := time.Now().Add(-time.Hour)
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@xQ2!", PasswordChanged: changed})).To(Succeed())
+			serv.SetClock(func() time.Time { return changed.Add(48 * time.Hour) })
+			close(release)
+			serv.WaitShadow()
+
+			Expect(out.String()).To(ContainSubstring(`"CandidateFailed":["CPA"]`))
+		})
+
+		It("should not describe the rules of the candidate.", func() {
+			reqs, _ := serv.Describe("en")
+			Expect(reqs).To(HaveLen(2))
+		})
+
+		It("should stop reporting when calling ClearCandidate().", func() {
+			serv.ClearCandidate()
+			var _ = serv.Validate(&pwdserv.Password{NewPassword: "Summer2017"})
+			serv.WaitShadow()
+			Expect(out.Len()).To(BeZero())
+			Expect(serv.Has("NoSeason")).To(BeFalse())
+		})
+	})
+
+	Context("given you have an invalid candidate configuration", func() {
+		It("should return the error when calling SetCandidate().", func() {
+			err := pwdserv.New().SetCandidate([]byte(`{"Normalization": "fold"}`), nil)
+			Expect(err).To(MatchError("Unknown Normalization 'fold'."))
+		})
+	})
+})
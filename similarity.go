@@ -0,0 +1,208 @@
+package pwdserv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HistoryDecrypter recovers the plaintext of a history entry, for deployments that
+// keep reversibly-encrypted history. See SetHistoryDecrypter.
+type HistoryDecrypter interface {
+	Decrypt(entry string) (string, error)
+}
+
+// PlainHistory is a HistoryDecrypter for history kept in plaintext.
+type PlainHistory struct{}
+
+// Decrypt returns the entry as is.
+func (PlainHistory) Decrypt(entry string) (string, error) {
+	return entry, nil
+}
+
+// HistoryHasher compares a password with a history hash, for hash-only deployments.
+// See SetHistoryHasher.
+type HistoryHasher interface {
+	// Matches reports whether hash is the hash of password. An error, like keys
+	// that can not be loaded, fails the validation.
+	Matches(password, hash string) (bool, error)
+}
+
+var (
+	errNoDecrypter = errors.New("No history decrypter set for the HistoryDistance.")
+	errNoHasher    = errors.New("No history hasher set for the HistoryVariants.")
+)
+
+// SetHistoryDecrypter sets the decrypter of the history entries for the
+// HistoryDistance of the CheckHistorySimilarity validator. Set it before
+// SetConfig, which rejects a HistoryDistance without a decrypter.
+func (z *PasswordService) SetHistoryDecrypter(d HistoryDecrypter) {
+	z.decrypter = d
+
+	if z.config != nil {
+		z.config.decrypter = d
+	}
+	if z.candidate != nil {
+		z.candidate.decrypter = d
+	}
+}
+
+// SetHistoryHasher sets the hasher of the HistoryVariants of the
+// CheckHistorySimilarity validator. Set it before SetConfig, which rejects
+// HistoryVariants without a hasher.
+func (z *PasswordService) SetHistoryHasher(h HistoryHasher) {
+	z.hasher = h
+
+	if z.config != nil {
+		z.config.hasher = h
+	}
+	if z.candidate != nil {
+		z.candidate.hasher = h
+	}
+}
+
+// CheckHistorySimilarity validator checks that the NewPassword is not a small change
+// of the OldPassword or the previous passwords in the HistoryWindow: within the
+// HistoryDistance of the decrypted history, or a variant of the NewPassword matching
+// the OldPassword or the hashed history with HistoryVariants.
+func CheckHistorySimilarity(password *Password, config *PasswordRules) (bool, error) {
+	if config.CheckHistorySimilarity == false {
+		return true, nil
+	}
+
+	newPassword := config.normalize(password.NewPassword)
+	history := historyWindow(password, config)
+
+	similar := false
+	if config.HistoryDistance > 0 {
+		if config.decrypter == nil {
+			return false, errNoDecrypter
+		}
+
+		similar = password.OldPassword != "" && similarTo(newPassword, config.normalize(password.OldPassword), config.HistoryDistance)
+		for i := 0; i < len(history) && similar == false; i++ {
+			previous, err := config.decrypter.Decrypt(history[i])
+			if err != nil {
+				return false, fmt.Errorf("Decrypting the password history failed: %w", err)
+			}
+			similar = similarTo(newPassword, config.normalize(previous), config.HistoryDistance)
+		}
+	}
+
+	if config.HistoryVariants && similar == false {
+		if config.hasher == nil {
+			return false, errNoHasher
+		}
+
+		variants := passwordVariants(newPassword)
+
+		// the OldPassword is plaintext, so the variants are compared directly
+		if oldPassword := config.normalize(password.OldPassword); oldPassword != "" {
+			for _, variant := range variants {
+				if secretEqual(variant, oldPassword) {
+					similar = true
+					break
+				}
+			}
+		}
+
+		for i := 0; i < len(history) && similar == false; i++ {
+			hash := strings.TrimSpace(history[i])
+			for _, variant := range variants {
+				matches, err := config.hasher.Matches(variant, hash)
+				if err != nil {
+					return false, fmt.Errorf("Checking the password history failed: %w", err)
+				}
+				if matches {
+					similar = true
+					break
+				}
+			}
+		}
+	}
+
+	if similar {
+		return false, errors.New("Password is too similar to one of your previous passwords.")
+	}
+
+	return true, nil
+}
+
+// similarTo reports whether the passwords, ignoring case, are different
+// but within the edit distance.
+func similarTo(x, y string, distance int) bool {
+	d := editDistance(strings.ToLower(x), strings.ToLower(y))
+	return d > 0 && d <= distance
+}
+
+// editDistance returns the Levenshtein distance between x and y, in runes.
+func editDistance(x, y string) int {
+	a, b := []rune(x), []rune(y)
+
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(min(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// variantSuffixes are the suffixes commonly added to or swapped on a password.
+var variantSuffixes = []string{"", "!", "@", "#", "$", "?", "*", "1", "!!"}
+
+// passwordVariants returns the common mutations of a password users make to get
+// past the history: the trailing number incremented or decremented, with and without
+// its leading zeros, and the trailing symbols removed, added or swapped. The password
+// itself is not included.
+func passwordVariants(password string) []string {
+	core := strings.TrimRightFunc(password, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	cores := []string{core}
+	stem := strings.TrimRightFunc(core, unicode.IsDigit)
+	if digits := core[len(stem):]; digits != "" {
+		cores = append(cores, stem)
+		if n, err := strconv.Atoi(digits); err == nil {
+			for _, d := range []int{-1, 1, -2, 2} {
+				if n+d >= 0 {
+					cores = append(cores, stem+fmt.Sprintf("%0*d", len(digits), n+d), stem+strconv.Itoa(n+d))
+				}
+			}
+		}
+	}
+
+	suffixes := append([]string{password[len(core):]}, variantSuffixes...)
+
+	var variants []string
+	seen := map[string]bool{password: true}
+	for _, c := range cores {
+		for _, suffix := range suffixes {
+			if v := c + suffix; v != "" && !seen[v] {
+				seen[v] = true
+				variants = append(variants, v)
+			}
+		}
+	}
+
+	return variants
+}
+
+func describeHistorySimilarity(_ string, c *PasswordRules) *Requirement {
+	return requirement(c.CheckHistorySimilarity, ValidatorHistorySimilarity, map[string]interface{}{"MinHistory": c.MinHistory})
+}
@@ -0,0 +1,112 @@
+package pwdserv_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/DigiRazor/pwdserv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// sha256Hasher is an unsalted test hasher.
+type sha256Hasher struct{}
+
+func (sha256Hasher) Matches(password, hash string) (bool, error) {
+	return hashOf(password) == hash, nil
+}
+
+func hashOf(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ = Describe("History similarity", func() {
+	var similar = errors.New("Password is too similar to one of your previous passwords.")
+
+	Context("given you keep the history in plaintext", func() {
+		var serv *pwdserv.PasswordService
+
+		BeforeEach(func() {
+			serv = pwdserv.New()
+			serv.SetHistoryDecrypter(pwdserv.PlainHistory{})
+			err := serv.SetConfig([]byte(`{"MinHistory": 3, "CheckHistorySimilarity": true, "HistoryDistance": 2}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject a password within the distance of a previous one.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", PasswordHistory: []string{"Spring2024!", "winter2023!"}})
+			Expect(err).To(BeEquivalentTo(similar))
+
+			err = serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", OldPassword: "Winter2023"})
+			Expect(err).To(BeEquivalentTo(similar))
+		})
+
+		It("should accept a password further away, or outside the window.", func() {
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "yVHn6?R@", PasswordHistory: []string{"Winter2023!"}})).To(Succeed())
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", PasswordHistory: []string{"a", "b", "Winter2023!"}})).To(Succeed())
+		})
+	})
+
+	Context("given you keep hashes of the history only", func() {
+		var serv *pwdserv.PasswordService
+
+		BeforeEach(func() {
+			serv = pwdserv.New()
+			serv.SetHistoryHasher(sha256Hasher{})
+			err := serv.SetConfig([]byte(`{"MinHistory": 3, "CheckHistorySimilarity": true, "HistoryVariants": true}`), nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject a password with a variant in the history.", func() {
+			for _, previous := range []string{"Winter2023!", "Winter2025!", "Winter2024", "Winter2024#", "Winter!"} {
+				err := serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", PasswordHistory: []string{hashOf(previous)}})
+				Expect(err).To(BeEquivalentTo(similar), previous)
+			}
+		})
+
+		It("should reject a password with a variant that is the OldPassword.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", OldPassword: "Winter2023!"})
+			Expect(err).To(BeEquivalentTo(similar))
+
+			Expect(serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", OldPassword: "Summer2023!"})).To(Succeed())
+		})
+
+		It("should probe incremented numbers with and without their leading zeros.", func() {
+			for _, previous := range []string{"Winter9", "Winter09", "Winter11"} {
+				err := serv.Validate(&pwdserv.Password{NewPassword: "Winter10", PasswordHistory: []string{hashOf(previous)}})
+				Expect(err).To(BeEquivalentTo(similar), previous)
+			}
+
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Winter9", PasswordHistory: []string{hashOf("Winter10")}})
+			Expect(err).To(BeEquivalentTo(similar))
+		})
+
+		It("should accept a password without a variant in the history.", func() {
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", PasswordHistory: []string{hashOf("Summer2024!"), hashOf("Winter2030!")}})
+			Expect(err).To(Succeed())
+		})
+	})
+
+	Context("given you have not set the decrypter or hasher", func() {
+		It("should return an error when calling SetConfig().", func() {
+			err := pwdserv.New().SetConfig([]byte(`{"MinHistory": 3, "CheckHistorySimilarity": true, "HistoryVariants": true}`), nil)
+			Expect(err).To(MatchError("No history hasher set for the HistoryVariants."))
+
+			err = pwdserv.New().SetConfig([]byte(`{"MinHistory": 3, "CheckHistorySimilarity": true, "HistoryDistance": 2}`), nil)
+			Expect(err).To(MatchError("No history decrypter set for the HistoryDistance."))
+		})
+
+		It("should return an error when the hasher is unset after SetConfig().", func() {
+			serv := pwdserv.New()
+			serv.SetHistoryHasher(sha256Hasher{})
+			Expect(serv.SetConfig([]byte(`{"MinHistory": 3, "CheckHistorySimilarity": true, "HistoryVariants": true}`), nil)).To(Succeed())
+			serv.SetHistoryHasher(nil)
+
+			err := serv.Validate(&pwdserv.Password{NewPassword: "Winter2024!", PasswordHistory: []string{"x"}})
+			Expect(err).To(MatchError("No history hasher set for the HistoryVariants."))
+		})
+	})
+})
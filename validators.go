@@ -1,27 +1,70 @@
 package pwdserv
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
-	"regexp"
 	"strings"
 )
 
 type validFunc struct {
 	name       string
-	validation Validation
+	validation ValidationContext
+	cheap      bool
+	blackList  bool
+	describe   Describer
+	disabled   bool
+	severity   Severity
 }
 
-func (n *validFunc) addFunc(name string, val Validation) {
+func (n *validFunc) addFunc(name string, val ValidationContext, opts ...ValidatorOption) {
 
 	n.name = name
 	n.validation = val
+	n.cheap = false
+	n.blackList = false
+	n.describe = nil
+	n.severity = SeverityError
+
+	for _, opt := range opts {
+		opt(n)
+	}
+}
+
+// ValidatorOption configures a validator when it is registered with Add or AddContext.
+type ValidatorOption func(*validFunc)
+
+// Cheap marks a validator as cheap to run. Cheap validators run first and serially,
+// as a filter before the other validators are started.
+func Cheap() ValidatorOption {
+	return func(n *validFunc) {
+		n.cheap = true
+	}
+}
+
+// classValidation is a build-in validation over the classes of the NewPassword.
+type classValidation func(charStats, *PasswordRules) (bool, error)
+
+// byClasses runs a classValidation with the classes computed once by Validate.
+func byClasses(val classValidation) ValidationContext {
+	return func(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+		return val(classesIn(ctx, password, config), config)
+	}
+}
+
+// blackListed marks a validator that reports its black list matches for the metrics,
+// through the hitKey of the context.
+func blackListed() ValidatorOption {
+	return func(n *validFunc) {
+		n.blackList = true
+	}
 }
 
 // ComfirmPassword validator checks the NewPassword against the ConfirmPassword.
 func ComfirmPassword(password *Password, config *PasswordRules) (bool, error) {
 	if config.CheckConfirm == true {
-		res := strings.TrimSpace(password.NewPassword) == strings.TrimSpace(password.ConfirmPassword)
+		res := secretEqual(config.normalize(password.NewPassword), config.normalize(password.ConfirmPassword))
 		if res == false {
 			return false, errors.New("Confirmation password does not match.")
 		}
@@ -33,7 +76,7 @@ func ComfirmPassword(password *Password, config *PasswordRules) (bool, error) {
 // CheckLength validator checks the NewPassword MinLength.
 func CheckLength(password *Password, config *PasswordRules) (bool, error) {
 	if config.CheckMinLength == true {
-		res := len(strings.TrimSpace(password.NewPassword)) >= config.MinLength
+		res := len(config.normalize(password.NewPassword)) >= config.MinLength
 
 		if res == false {
 			err := fmt.Sprintf("Passwords must be a minimum of %d characters.", config.MinLength)
@@ -47,12 +90,7 @@ func CheckLength(password *Password, config *PasswordRules) (bool, error) {
 // CheckUserID validator checks the NewPassword against the UserID.
 func CheckUserID(password *Password, config *PasswordRules) (bool, error) {
 	if config.CheckUserID == true {
-		lowerUID := strings.ToLower(password.UserID)
-		lowerPass := strings.ToLower(password.NewPassword)
-
-		indx := strings.Index(lowerPass, lowerUID)
-
-		if indx >= 0 {
+		if containsFold(password.NewPassword, password.UserID) {
 			return false, errors.New("Password may not contain the UserID/ Username.")
 		}
 	}
@@ -62,8 +100,12 @@ func CheckUserID(password *Password, config *PasswordRules) (bool, error) {
 
 // CheckUppercase validator checks the NewPassword for upper-case characters.
 func CheckUppercase(password *Password, config *PasswordRules) (bool, error) {
+	return checkUppercase(config.classes(password), config)
+}
+
+func checkUppercase(st charStats, config *PasswordRules) (bool, error) {
 	if config.CheckUppercase == true {
-		res, _ := regexp.MatchString("(.*[A-Z])", password.NewPassword)
+		res := st.upper > 0
 
 		if res == false {
 			return false, errors.New("Password must contain at least 1 Capital letter.")
@@ -75,8 +117,12 @@ func CheckUppercase(password *Password, config *PasswordRules) (bool, error) {
 
 // CheckLowercase validator checks the NewPassword for lower-case characters.
 func CheckLowercase(password *Password, config *PasswordRules) (bool, error) {
+	return checkLowercase(config.classes(password), config)
+}
+
+func checkLowercase(st charStats, config *PasswordRules) (bool, error) {
 	if config.CheckLowercase == true {
-		res, _ := regexp.MatchString("(.*[a-z])", password.NewPassword)
+		res := st.lower > 0
 
 		if res == false {
 			return false, errors.New("Password must contain at least 1 lower case character.")
@@ -88,8 +134,12 @@ func CheckLowercase(password *Password, config *PasswordRules) (bool, error) {
 
 // CheckNumeric validator checks the NewPassword for numeric characters.
 func CheckNumeric(password *Password, config *PasswordRules) (bool, error) {
+	return checkNumeric(config.classes(password), config)
+}
+
+func checkNumeric(st charStats, config *PasswordRules) (bool, error) {
 	if config.CheckNumeric == true {
-		res, _ := regexp.MatchString("(.*[0-9])", password.NewPassword)
+		res := st.numeric > 0
 
 		if res == false {
 			return false, errors.New("Password must contain at least 1 numeric character.")
@@ -101,14 +151,13 @@ func CheckNumeric(password *Password, config *PasswordRules) (bool, error) {
 
 // CheckSpecialChar validator checks the NewPassword for special characters.
 func CheckSpecialChar(password *Password, config *PasswordRules) (bool, error) {
+	return checkSpecialChar(config.classes(password), config)
+}
+
+func checkSpecialChar(st charStats, config *PasswordRules) (bool, error) {
 	if config.CheckSpecialChar == true {
-		str := config.SpecialChar
-		for _, r := range str {
-			elem := string(r)
-			indx := strings.Index(password.NewPassword, elem)
-			if indx >= 0 {
-				return true, nil
-			}
+		if st.special > 0 {
+			return true, nil
 		}
 		err := fmt.Sprintf("Password must contain at least 1 of the following characters: '%s'.", config.SpecialChar)
 		return false, errors.New(err)
@@ -119,8 +168,12 @@ func CheckSpecialChar(password *Password, config *PasswordRules) (bool, error) {
 
 // CheckWhiteSpace validator checks the NewPassword for white space.
 func CheckWhiteSpace(password *Password, config *PasswordRules) (bool, error) {
+	return checkWhiteSpace(config.classes(password), config)
+}
+
+func checkWhiteSpace(st charStats, config *PasswordRules) (bool, error) {
 	if config.CheckWhiteSpace == true {
-		res, _ := regexp.MatchString("(.*[\\s])", password.NewPassword)
+		res := st.space > 0
 
 		if res == true {
 			return false, errors.New("Space is not allowed.")
@@ -130,48 +183,164 @@ func CheckWhiteSpace(password *Password, config *PasswordRules) (bool, error) {
 	return true, nil
 }
 
-// CheckHistory validator checks the NewPasswordHash against the PasswordHistory
+// CheckHistory validator checks the NewPassword against the OldPassword, and the
+// NewPasswordHash against the previous passwords in the HistoryWindow.
 func CheckHistory(password *Password, config *PasswordRules) (bool, error) {
 	if config.CheckHistory == true {
-		err := fmt.Sprintf("You are also not allowed to use any of your previous %d passwords.", config.MinHistory)
-		if password.NewPassword == password.OldPassword {
-			return false, errors.New(err)
-		}
+		reused := secretEqual(config.normalize(password.NewPassword), config.normalize(password.OldPassword))
+
+		if reused == false {
+			for _, hash := range historyWindow(password, config) {
+				// hashes are trimmed of storage padding, whatever the Normalization
+				if secretEqual(strings.TrimSpace(password.NewPasswordHash), strings.TrimSpace(hash)) {
+					reused = true
+					break
+				}
 
-		if len(password.PasswordHistory) > 0 {
-			// In his majesty's service, one must always choose the lesser of two weevils
-			count := min(config.MinHistory-1, len(password.PasswordHistory))
-			for i := 0; i < count; i++ {
-				res := strings.TrimSpace(password.NewPasswordHash) == strings.TrimSpace(password.PasswordHistory[i])
-				if res == true {
-					return false, errors.New(err)
+				// keyed or salted hashes are verified with the hasher, which
+				// tries the previous keys after a rotation
+				if config.hasher != nil {
+					matches, err := config.hasher.Matches(config.normalize(password.NewPassword), strings.TrimSpace(hash))
+					if err != nil {
+						return false, fmt.Errorf("Checking the password history failed: %w", err)
+					}
+					if matches {
+						reused = true
+						break
+					}
 				}
 			}
 		}
+
+		if reused == true {
+			return false, historyError(config)
+		}
 	}
 
 	return true, nil
 }
 
+// historyError describes the HistoryWindow that a reused password is in.
+func historyError(config *PasswordRules) error {
+	switch config.HistoryWindow {
+	case HistoryTime:
+		return fmt.Errorf("You are not allowed to use a password you used in the last %d day(s).", config.HistoryDays)
+	case HistoryAll:
+		return errors.New("You are not allowed to use any of your previous passwords.")
+	}
+
+	err := fmt.Sprintf("You are also not allowed to use any of your previous %d passwords.", config.MinHistory)
+	return errors.New(err)
+}
+
 // CheckBlackList validator checks the NewPassword against the BlackList
+// and the embedded BlackLists.
 func CheckBlackList(password *Password, config *PasswordRules) (bool, error) {
+	match, err := blackListMatch(password, config)
+	if err == nil {
+		err = match
+	}
+
+	return err == nil, err
+}
+
+// hitKey is the context key of the black list hit of a validator marked blackListed.
+type hitKey struct{}
+
+// checkBlackList is the CheckBlackList of the build-ins, which marks a match
+// as a black list hit for the metrics.
+func checkBlackList(ctx context.Context, password *Password, config *PasswordRules) (bool, error) {
+	match, err := blackListMatch(password, config)
+	if err != nil {
+		return false, err
+	}
+
+	if match != nil {
+		if hit, ok := ctx.Value(hitKey{}).(*bool); ok {
+			*hit = true
+		}
+		return false, match
+	}
+
+	return true, nil
+}
+
+// blackListMatch returns the error for a NewPassword matching a black list, and
+// the error of a black list that could not be checked, like the keys of the index.
+func blackListMatch(password *Password, config *PasswordRules) (match error, err error) {
 	if config.CheckBlackList == true {
 
-		if len(config.BlackList) > 0 {
-			lowerPass := strings.ToLower(password.NewPassword)
+		if len(config.BlackList) > 0 && config.BlackListMatch == MatchExact {
+			newPassword := config.normalize(password.NewPassword)
 			for i := 0; i < len(config.BlackList); i++ {
-				n := strings.Count(lowerPass, config.BlackList[i])
-				if n > 0 {
+				if strings.EqualFold(newPassword, config.BlackList[i]) {
+					return errBlackListed, nil
+				}
+			}
+		} else if len(config.BlackList) > 0 {
+			for i := 0; i < len(config.BlackList); i++ {
+				if containsFold(password.NewPassword, config.BlackList[i]) {
 					err := fmt.Sprintf("Password contains black listed word '%s'.", config.BlackList[i])
-					return false, errors.New(err)
+					return errors.New(err), nil
 				}
 			}
 		}
+
+		for _, list := range config.blackLists {
+			if err := list.check(config.normalize(password.NewPassword)); err != nil {
+				return err, nil
+			}
+		}
+
+		if config.index != nil {
+			word, found, err := config.index.match(config.normalize(password.NewPassword), config.BlackListMatch == MatchExact)
+			if err != nil {
+				return nil, err
+			}
+			if found && config.BlackListMatch == MatchExact {
+				return errBlackListed, nil
+			}
+			if found {
+				err := fmt.Sprintf("Password contains black listed word '%s'.", word)
+				return errors.New(err), nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// CheckPasswordAge validator checks that the current password is older than the MinPasswordAge.
+func CheckPasswordAge(password *Password, config *PasswordRules) (bool, error) {
+	if config.CheckPasswordAge == true && config.MinPasswordAge > 0 && !password.PasswordChanged.IsZero() {
+		age := config.now().Sub(password.PasswordChanged)
+
+		if age < days(config.MinPasswordAge) {
+			err := fmt.Sprintf("Password may only be changed once every %d day(s).", config.MinPasswordAge)
+			return false, errors.New(err)
+		}
 	}
 
 	return true, nil
 }
 
+// secretEqual compares secrets in constant time, so the comparison does not leak
+// how much of the secrets match. Only the length may be learnt from the timing.
+// Like subtle.ConstantTimeCompare, but on the strings, so no copies of the
+// secrets are left on the heap.
+func secretEqual(x, y string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+
+	var v byte
+	for i := 0; i < len(x); i++ {
+		v |= x[i] ^ y[i]
+	}
+
+	return subtle.ConstantTimeByteEq(v, 0) == 1
+}
+
 func min(x, y int) int {
 	if x < y {
 		return x